@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// binding menghubungkan satu field Config dengan key di file konfigurasi
+// dan nama environment variable-nya.
+type binding struct {
+	key    string // "section.key" di file TOML
+	env    string
+	secret bool // disamarkan oleh Print
+	target interface{}
+	// years menandai target *[]int yang dibaca dengan ParseYears
+	years bool
+}
+
+// bindings mengembalikan semua field yang bisa diatur, dalam urutan yang
+// juga dipakai oleh Print.
+func (c *Config) bindings() []binding {
+	return []binding{
+		{key: "api.url", env: "API_URL", target: &c.APIURL},
+		{key: "api.login_url", env: "API_LOGIN_URL", target: &c.APILoginURL},
+		{key: "api.username", env: "API_USERNAME", target: &c.APIUsername},
+		{key: "api.password", env: "API_PASSWORD", secret: true, target: &c.APIPassword},
+		{key: "api.tahun", env: "API_DATA_TAHUN", target: &c.APIDataTahun, years: true},
+		{key: "api.max_response_bytes", env: "API_MAX_RESPONSE_BYTES", target: &c.APIMaxResponseBytes},
+		{key: "api.page_timeout", env: "API_PAGE_TIMEOUT", target: &c.APIPageTimeout},
+
+		{key: "retry.max_attempts", env: "API_RETRY_MAX_ATTEMPTS", target: &c.APIRetryMaxAttempts},
+		{key: "retry.base_delay", env: "API_RETRY_BASE_DELAY", target: &c.APIRetryBaseDelay},
+		{key: "retry.max_delay", env: "API_RETRY_MAX_DELAY", target: &c.APIRetryMaxDelay},
+		{key: "retry.jitter", env: "API_RETRY_JITTER", target: &c.APIRetryJitter},
+		{key: "retry.status_codes", env: "API_RETRY_STATUS_CODES", target: &c.APIRetryStatusCodes},
+
+		{key: "rate_limit.rps", env: "API_RATE_LIMIT_RPS", target: &c.APIRateLimitRPS},
+		{key: "rate_limit.burst", env: "API_RATE_LIMIT_BURST", target: &c.APIRateLimitBurst},
+
+		{key: "db.url", env: "DATABASE_URL", secret: true, target: &c.DatabaseURL},
+		{key: "db.max_open_conns", env: "DB_MAX_OPEN_CONNS", target: &c.DBMaxOpenConns},
+		{key: "db.max_idle_conns", env: "DB_MAX_IDLE_CONNS", target: &c.DBMaxIdleConns},
+		{key: "db.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", target: &c.DBConnMaxLifetime},
+		{key: "db.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", target: &c.DBConnMaxIdleTime},
+
+		{key: "sync.workers", env: "SYNC_WORKERS", target: &c.SyncWorkers},
+		{key: "sync.failure_policy", env: "SYNC_FAILURE_POLICY", target: &c.SyncFailurePolicy},
+		{key: "sync.max_failures", env: "SYNC_MAX_FAILURES", target: &c.SyncMaxFailures},
+		{key: "sync.max_failure_ratio", env: "SYNC_MAX_FAILURE_RATIO", target: &c.SyncMaxFailureRatio},
+		{key: "sync.retry_passes", env: "SYNC_RETRY_PASSES", target: &c.SyncRetryPasses},
+		{key: "sync.retry_cooldown", env: "SYNC_RETRY_COOLDOWN", target: &c.SyncRetryCooldown},
+		{key: "sync.run_timeout", env: "SYNC_RUN_TIMEOUT", target: &c.SyncRunTimeout},
+		{key: "sync.region_timeout", env: "SYNC_REGION_TIMEOUT", target: &c.SyncRegionTimeout},
+		{key: "sync.granularity", env: "SYNC_GRANULARITY", target: &c.SyncGranularity},
+		{key: "sync.discovery_interval", env: "SYNC_DISCOVERY_INTERVAL", target: &c.SyncDiscoveryInterval},
+
+		{key: "store.mode", env: "STORE_MODE", target: &c.StoreMode},
+		{key: "store.batch_size", env: "STORE_BATCH_SIZE", target: &c.StoreBatchSize},
+		{key: "store.commit_mode", env: "STORE_COMMIT_MODE", target: &c.StoreCommitMode},
+		{key: "store.reconcile", env: "STORE_RECONCILE", target: &c.StoreReconcile},
+		{key: "store.history", env: "STORE_HISTORY", target: &c.StoreHistory},
+		{key: "store.tx_timeout", env: "STORE_TX_TIMEOUT", target: &c.StoreTxTimeout},
+
+		{key: "wilayah.source", env: "WILAYAH_SOURCE", target: &c.WilayahSource},
+		{key: "wilayah.table", env: "WILAYAH_TABLE", target: &c.WilayahTable},
+		{key: "wilayah.provinsi_column", env: "WILAYAH_PROVINSI_COLUMN", target: &c.WilayahProvinsiColumn},
+		{key: "wilayah.kabupaten_column", env: "WILAYAH_KABUPATEN_COLUMN", target: &c.WilayahKabupatenColumn},
+		{key: "wilayah.csv_path", env: "WILAYAH_CSV_PATH", target: &c.WilayahCSVPath},
+	}
+}
+
+// set mem-parse raw sesuai tipe field lalu menyimpannya. Daftar integer
+// ditulis dipisahkan koma (misal "429,503").
+func (b binding) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	if b.years {
+		years, err := ParseYears(raw)
+		if err != nil {
+			return err
+		}
+		*b.target.(*[]int) = years
+		return nil
+	}
+
+	switch t := b.target.(type) {
+	case *string:
+		*t = raw
+	case *int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q bukan bilangan bulat", raw)
+		}
+		*t = n
+	case *int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q bukan bilangan bulat", raw)
+		}
+		*t = n
+	case *float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q bukan angka", raw)
+		}
+		*t = f
+	case *bool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q bukan boolean", raw)
+		}
+		*t = v
+	case *time.Duration:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q bukan durasi (contoh: 30s, 5m)", raw)
+		}
+		*t = d
+	case *[]int:
+		var list []int
+		for _, part := range strings.Split(raw, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return fmt.Errorf("%q bukan daftar bilangan bulat", raw)
+			}
+			list = append(list, n)
+		}
+		*t = list
+	default:
+		return fmt.Errorf("tipe field %T tidak didukung", b.target)
+	}
+	return nil
+}
+
+// tomlValue memformat nilai field sebagai nilai TOML.
+func (b binding) tomlValue() string {
+	switch t := b.target.(type) {
+	case *string:
+		return strconv.Quote(*t)
+	case *int:
+		return strconv.Itoa(*t)
+	case *int64:
+		return strconv.FormatInt(*t, 10)
+	case *float64:
+		return strconv.FormatFloat(*t, 'g', -1, 64)
+	case *bool:
+		return strconv.FormatBool(*t)
+	case *time.Duration:
+		return strconv.Quote(t.String())
+	case *[]int:
+		parts := make([]string, len(*t))
+		for i, n := range *t {
+			parts[i] = strconv.Itoa(n)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	default:
+		return ""
+	}
+}
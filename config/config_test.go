@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+)
+
+func TestValidateDefault(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("Default().Validate() = %v, want nil", err)
+	}
+}
+
+func TestValidateSyncOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr string
+	}{
+		{"unknown failure policy", func(c *Config) { c.SyncFailurePolicy = "stop" }, "sync.failure_policy: unknown failure policy"},
+		{"ratio out of range", func(c *Config) {
+			c.SyncFailurePolicy = "max-failure-ratio"
+			c.SyncMaxFailureRatio = 1.5
+		}, "sync.failure_policy: max failure ratio must be between 0 and 1"},
+		{"negative max failures", func(c *Config) {
+			c.SyncFailurePolicy = "max-failures"
+			c.SyncMaxFailures = -1
+		}, "sync.failure_policy: max failures must be >= 0"},
+		{"unknown granularity", func(c *Config) { c.SyncGranularity = "kota" }, "sync.granularity: unknown granularity"},
+		{"negative discovery interval", func(c *Config) { c.SyncDiscoveryInterval = -time.Hour }, "sync.discovery_interval tidak boleh negatif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Default()
+			tt.modify(c)
+			var invalid *customErrors.ErrInvalidConfig
+			if err := c.Validate(); !errors.As(err, &invalid) {
+				t.Fatalf("got error %v, want ErrInvalidConfig", err)
+			}
+			if len(invalid.Problems) != 1 || !strings.Contains(invalid.Problems[0], tt.wantErr) {
+				t.Errorf("got problems %q, want one containing %q", invalid.Problems, tt.wantErr)
+			}
+		})
+	}
+}
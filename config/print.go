@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const redacted = "********"
+
+// Print menulis konfigurasi efektif dalam format TOML yang sama dengan file
+// konfigurasi, dengan password dan kredensial di URL database disamarkan.
+func (c *Config) Print(w io.Writer) error {
+	section := ""
+	for _, b := range c.bindings() {
+		sec, name, _ := strings.Cut(b.key, ".")
+		if sec != section {
+			if section != "" {
+				if _, err := fmt.Fprintln(w); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "[%s]\n", sec); err != nil {
+				return err
+			}
+			section = sec
+		}
+
+		value := b.tomlValue()
+		if b.secret {
+			value = strconv.Quote(redact(*b.target.(*string)))
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s  # %s\n", name, value, b.env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// redact menyamarkan nilai rahasia. Untuk URL hanya password-nya yang
+// disamarkan agar host dan nama database tetap terlihat.
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return u.Redacted()
+	}
+	return redacted
+}
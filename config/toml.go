@@ -0,0 +1,243 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// fileValue adalah satu pasangan key = value dari file konfigurasi.
+type fileValue struct {
+	key   string // "section.key"
+	value string // Nilai mentah; array digabung dengan koma
+	line  int
+}
+
+// parseTOML membaca subset TOML yang cukup untuk file konfigurasi ini:
+// header [section], key = value, string ("..." atau '...'), angka, boolean,
+// array (boleh multi-baris) dan komentar #. Tabel bersarang, array bersarang
+// dan string multi-baris tidak didukung.
+func parseTOML(content string) ([]fileValue, error) {
+	var values []fileValue
+	seen := make(map[string]int)
+	section := ""
+
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i]))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: header section tidak valid: %s", lineNo, line)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: nama section kosong", lineNo)
+			}
+			continue
+		}
+
+		name, raw, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: baris harus berbentuk key = value", lineNo)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("line %d: key kosong", lineNo)
+		}
+		key := name
+		if section != "" {
+			key = section + "." + name
+		}
+		if prev, dup := seen[key]; dup {
+			return nil, fmt.Errorf("line %d: key %s sudah didefinisikan di line %d", lineNo, key, prev)
+		}
+		seen[key] = lineNo
+
+		raw = strings.TrimSpace(raw)
+		// Array multi-baris: gabungkan baris berikutnya sampai kurung ditutup
+		for strings.HasPrefix(raw, "[") && !arrayClosed(raw) {
+			i++
+			if i >= len(lines) {
+				return nil, fmt.Errorf("line %d: %s: array tidak ditutup", lineNo, key)
+			}
+			raw += " " + strings.TrimSpace(stripComment(lines[i]))
+		}
+
+		value, err := parseTOMLValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", lineNo, key, err)
+		}
+		values = append(values, fileValue{key: key, value: value, line: lineNo})
+	}
+	return values, nil
+}
+
+// parseTOMLValue mengubah satu nilai TOML menjadi string mentah.
+func parseTOMLValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("nilai kosong")
+	case strings.HasPrefix(raw, `"`), strings.HasPrefix(raw, "'"):
+		return parseTOMLString(raw)
+	case strings.HasPrefix(raw, "["):
+		return parseTOMLArray(raw)
+	case raw == "true", raw == "false":
+		return raw, nil
+	default:
+		return parseTOMLNumber(raw)
+	}
+}
+
+// parseTOMLString membaca string basic ("...") atau literal ('...').
+func parseTOMLString(raw string) (string, error) {
+	if strings.HasPrefix(raw, "'") {
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") || strings.Contains(raw[1:len(raw)-1], "'") {
+			return "", fmt.Errorf("string tidak valid: %s", raw)
+		}
+		return raw[1 : len(raw)-1], nil
+	}
+	s, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", fmt.Errorf("string tidak valid: %s", raw)
+	}
+	return s, nil
+}
+
+// parseTOMLArray membaca array satu dimensi; elemen digabung dengan koma,
+// sehingga elemen string tidak boleh mengandung koma.
+func parseTOMLArray(raw string) (string, error) {
+	if !strings.HasSuffix(raw, "]") {
+		return "", fmt.Errorf("array tidak valid: %s", raw)
+	}
+	body := raw[1 : len(raw)-1]
+	if strings.TrimSpace(body) == "" {
+		return "", nil
+	}
+	items, err := splitArray(body)
+	if err != nil {
+		return "", err
+	}
+	values := make([]string, 0, len(items))
+	for i, item := range items {
+		if item = strings.TrimSpace(item); item == "" {
+			// Koma setelah elemen terakhir diperbolehkan seperti di TOML
+			if i == len(items)-1 && i > 0 {
+				continue
+			}
+			return "", fmt.Errorf("array berisi elemen kosong: %s", raw)
+		}
+		if strings.HasPrefix(item, "[") {
+			return "", fmt.Errorf("array bersarang tidak didukung: %s", raw)
+		}
+		v, err := parseTOMLValue(item)
+		if err != nil {
+			return "", err
+		}
+		if strings.Contains(v, ",") {
+			return "", fmt.Errorf("elemen array tidak boleh mengandung koma: %s", item)
+		}
+		values = append(values, v)
+	}
+	return strings.Join(values, ","), nil
+}
+
+// parseTOMLNumber menerima integer atau float TOML. Underscore hanya boleh di
+// antara dua digit (1_000) dan dibuang; nilai tanpa kutip lainnya ditolak
+// agar string yang lupa dikutip tidak diam-diam diubah.
+func parseTOMLNumber(raw string) (string, error) {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '_' && (i == 0 || i == len(raw)-1 || !isDigit(raw[i-1]) || !isDigit(raw[i+1])) {
+			return "", fmt.Errorf("nilai %s tidak valid; string harus diberi kutip", raw)
+		}
+	}
+	number := strings.ReplaceAll(raw, "_", "")
+	if _, err := strconv.ParseInt(number, 10, 64); err == nil {
+		return number, nil
+	}
+	// ParseFloat juga menerima inf, nan dan hex float; hanya bentuk desimal yang dipakai
+	if _, err := strconv.ParseFloat(number, 64); err == nil && strings.Trim(number, "0123456789+-.eE") == "" {
+		return number, nil
+	}
+	return "", fmt.Errorf("nilai %s bukan angka atau boolean; string harus diberi kutip", raw)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// splitArray memecah isi array pada koma yang tidak berada di dalam string.
+func splitArray(body string) ([]string, error) {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			items = append(items, body[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("string dalam array tidak ditutup: [%s]", body)
+	}
+	return append(items, body[start:]), nil
+}
+
+// arrayClosed bernilai true jika kurung array di raw sudah ditutup.
+func arrayClosed(raw string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		}
+	}
+	return depth <= 0
+}
+
+// stripComment membuang komentar # yang tidak berada di dalam string.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
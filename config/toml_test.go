@@ -0,0 +1,118 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []fileValue
+	}{
+		{
+			name:    "sections and comments",
+			content: "# konfigurasi\n[api]\nurl = \"https://api.example/data\" # endpoint\n\n[db]\nmax_open_conns = 10\n",
+			want: []fileValue{
+				{key: "api.url", value: "https://api.example/data", line: 3},
+				{key: "db.max_open_conns", value: "10", line: 6},
+			},
+		},
+		{
+			name:    "top-level key",
+			content: "log_level = 'warn'\n",
+			want:    []fileValue{{key: "log_level", value: "warn", line: 1}},
+		},
+		{
+			name:    "strings",
+			content: "a = \"x # bukan komentar\"\nb = 'C:\\path#1'\nc = \"kutip \\\"dalam\\\"\"\n",
+			want: []fileValue{
+				{key: "a", value: "x # bukan komentar", line: 1},
+				{key: "b", value: `C:\path#1`, line: 2},
+				{key: "c", value: `kutip "dalam"`, line: 3},
+			},
+		},
+		{
+			name:    "numbers and booleans",
+			content: "a = 1_000\nb = -5\nc = 0.25\nd = 1e3\ne = true\nf = false\n",
+			want: []fileValue{
+				{key: "a", value: "1000", line: 1},
+				{key: "b", value: "-5", line: 2},
+				{key: "c", value: "0.25", line: 3},
+				{key: "d", value: "1e3", line: 4},
+				{key: "e", value: "true", line: 5},
+				{key: "f", value: "false", line: 6},
+			},
+		},
+		{
+			name:    "arrays",
+			content: "a = [429, 503]\nb = [\"51\", '52',]\nc = []\n",
+			want: []fileValue{
+				{key: "a", value: "429,503", line: 1},
+				{key: "b", value: "51,52", line: 2},
+				{key: "c", value: "", line: 3},
+			},
+		},
+		{
+			name:    "multi-line array",
+			content: "[retry]\nstatus_codes = [\n  429, # too many requests\n  503,\n]\nmax_attempts = 3\n",
+			want: []fileValue{
+				{key: "retry.status_codes", value: "429,503", line: 2},
+				{key: "retry.max_attempts", value: "3", line: 6},
+			},
+		},
+		{
+			name:    "empty file",
+			content: "\n# hanya komentar\n",
+			want:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML(tt.content)
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unclosed header", "[api\n", "line 1: header section tidak valid"},
+		{"array of tables", "a = 1\n[[api]]\n", "line 2: header section tidak valid"},
+		{"empty section", "[ ]\n", "line 1: nama section kosong"},
+		{"no equals", "\n\nurl\n", "line 3: baris harus berbentuk key = value"},
+		{"empty key", " = 1\n", "line 1: key kosong"},
+		{"duplicate key", "[api]\nurl = 'a'\n\nurl = 'b'\n", "line 4: key api.url sudah didefinisikan di line 2"},
+		{"duplicate section key", "[s]\nb = 1\n[s]\nb = 2\n", "line 4: key s.b sudah didefinisikan di line 2"},
+		{"empty value", "a =\n", "line 1: a: nilai kosong"},
+		{"unquoted string", "[api]\nurl = https://x\n", "line 2: api.url: nilai https://x bukan angka atau boolean"},
+		{"special float", "a = inf\n", "line 1: a: nilai inf bukan angka"},
+		{"misplaced underscore", "a = 1__0\n", "line 1: a: nilai 1__0 tidak valid"},
+		{"unterminated string", "a = \"abc\n", "line 1: a: string tidak valid"},
+		{"quote inside literal", "a = 'it's'\n", "line 1: a: string tidak valid"},
+		{"unclosed array", "a = [1,\n2\n", "line 1: a: array tidak ditutup"},
+		{"nested array", "a = [[1], [2]]\n", "line 1: a: array bersarang tidak didukung"},
+		{"empty element", "a = [1,,2]\n", "line 1: a: array berisi elemen kosong"},
+		{"comma in element", "a = [\"x,y\"]\n", "line 1: a: elemen array tidak boleh mengandung koma"},
+		{"unclosed string in array", "a = [\"x]\n", "line 1: a: array tidak ditutup"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML(tt.content)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
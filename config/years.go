@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxYearSpan membatasi panjang rentang tahun agar salah ketik seperti
+// "2023-20250" tidak menghasilkan ribuan tahun.
+const maxYearSpan = 50
+
+// ParseYears membaca daftar tahun yang dipisahkan koma, di mana setiap
+// elemen boleh berupa rentang inklusif, misal "2023-2025" atau "2021,2023-2024".
+// Hasilnya diurutkan dan tanpa duplikat.
+func ParseYears(raw string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("%q bukan tahun", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("%q bukan rentang tahun", part)
+			}
+		}
+		if end < start || end-start > maxYearSpan {
+			return nil, fmt.Errorf("rentang tahun %q tidak valid", part)
+		}
+		for y := start; y <= end; y++ {
+			seen[y] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, fmt.Errorf("daftar tahun kosong")
+	}
+
+	years := make([]int, 0, len(seen))
+	for y := range seen {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	return years, nil
+}
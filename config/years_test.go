@@ -0,0 +1,66 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseYears(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want []int
+	}{
+		{"2024", []int{2024}},
+		{" 2024 ", []int{2024}},
+		{"2023-2025", []int{2023, 2024, 2025}},
+		{"2023 - 2024", []int{2023, 2024}},
+		{"2025-2025", []int{2025}},
+		{"2021,2023-2024", []int{2021, 2023, 2024}},
+		// Urutan masukan tidak penting; duplikat dan rentang yang tumpang tindih digabung
+		{"2025,2021,2023-2024,2024,2022-2023", []int{2021, 2022, 2023, 2024, 2025}},
+		{"2024,,2025,", []int{2024, 2025}},
+		{"2000-2050", func() []int {
+			var years []int
+			for y := 2000; y <= 2050; y++ {
+				years = append(years, y)
+			}
+			return years
+		}()},
+	}
+	for _, tt := range tests {
+		got, err := ParseYears(tt.raw)
+		if err != nil {
+			t.Errorf("ParseYears(%q): %v", tt.raw, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ParseYears(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseYearsErrors(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr string
+	}{
+		{"", "daftar tahun kosong"},
+		{" , ", "daftar tahun kosong"},
+		{"tahun", `"tahun" bukan tahun`},
+		{"2024a", `"2024a" bukan tahun`},
+		{"-2024", `"-2024" bukan tahun`},
+		{"2024-", `"2024-" bukan rentang tahun`},
+		{"2023-2025-2027", `"2023-2025-2027" bukan rentang tahun`},
+		{"2025-2023", `rentang tahun "2025-2023" tidak valid`},
+		{"2023-20250", `rentang tahun "2023-20250" tidak valid`},
+		{"2000-2051", `rentang tahun "2000-2051" tidak valid`},
+		{"2024,x", `"x" bukan tahun`},
+	}
+	for _, tt := range tests {
+		_, err := ParseYears(tt.raw)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseYears(%q) error = %v, want it to contain %q", tt.raw, err, tt.wantErr)
+		}
+	}
+}
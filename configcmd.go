@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+)
+
+// runConfig menjalankan subcommand "config print|validate". print menampilkan
+// konfigurasi efektif (setelah file, env dan -tahun diterapkan) dengan rahasia
+// disamarkan; keduanya mengembalikan exitUsage jika konfigurasi tidak valid.
+func runConfig(cfg *config.Config, logger *log.Logger, args []string) int {
+	fs := newFlagSet("config", "print|validate")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	switch fs.Arg(0) {
+	case "print":
+		if err := cfg.Print(os.Stdout); err != nil {
+			logging.Errorf(logger, "ERROR: %v", err)
+			return exitFatal
+		}
+	case "validate":
+	default:
+		fs.Usage()
+		return exitUsage
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if fs.Arg(0) == "validate" {
+		fmt.Println("Konfigurasi valid.")
+	}
+	return exitOK
+}
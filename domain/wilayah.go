@@ -0,0 +1,8 @@
+package domain
+
+// Wilayah adalah satu kabupaten/kota. Daftar wilayah disediakan oleh
+// paket wilayah; tag db dipakai sebagai alias kolom oleh sumber SQL.
+type Wilayah struct {
+	KodeProvinsi  string `db:"provinsi_id"`
+	KodeKabupaten string `db:"kota_id"`
+}
@@ -1,11 +1,17 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+	"time"
+)
 
 // ErrAPICallFailed adalah error ketika panggilan ke API eksternal gagal.
 type ErrAPICallFailed struct {
 	StatusCode int
 	Message    string
+	// RetryAfter diisi dari header Retry-After jika server mengirimkannya.
+	RetryAfter time.Duration
 }
 
 func (e *ErrAPICallFailed) Error() string {
@@ -25,3 +31,113 @@ func (e *ErrDBOperationFailed) Error() string {
 func (e *ErrDBOperationFailed) Unwrap() error {
 	return e.Err
 }
+
+// ErrResponseTooLarge adalah error ketika body response API melebihi batas ukuran.
+type ErrResponseTooLarge struct {
+	URL   string
+	Limit int64
+}
+
+func (e *ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("response from %s exceeds limit of %d bytes", e.URL, e.Limit)
+}
+
+// ErrResponseTruncated adalah error ketika body response API terpotong sebelum selesai dibaca.
+type ErrResponseTruncated struct {
+	URL string
+	Err error
+}
+
+func (e *ErrResponseTruncated) Error() string {
+	return fmt.Sprintf("response from %s is truncated: %v", e.URL, e.Err)
+}
+
+func (e *ErrResponseTruncated) Unwrap() error {
+	return e.Err
+}
+
+// ErrPageTimeout adalah error ketika satu request halaman melewati batas
+// waktunya, sementara context induknya masih aktif sehingga masih bisa diulang.
+type ErrPageTimeout struct {
+	URL     string
+	Timeout time.Duration
+}
+
+func (e *ErrPageTimeout) Error() string {
+	return fmt.Sprintf("request for %s timed out after %s", e.URL, e.Timeout)
+}
+
+// ErrRegionFailed adalah error ketika sinkronisasi satu kabupaten/kota (atau
+// kecamatan/desa di dalamnya, jika KodeKecamatan/KodeDesa diisi) gagal.
+// Stage menunjukkan tahap yang gagal: "fetch", "validate", "store", "reconcile"
+// atau "commit".
+type ErrRegionFailed struct {
+	Tahun         int
+	KodeProvinsi  string
+	KodeKabupaten string
+	KodeKecamatan string
+	KodeDesa      string
+	Stage         string
+	Err           error
+}
+
+func (e *ErrRegionFailed) Error() string {
+	region := e.KodeProvinsi + "." + e.KodeKabupaten
+	for _, kode := range []string{e.KodeKecamatan, e.KodeDesa} {
+		if kode != "" {
+			region += "." + kode
+		}
+	}
+	return fmt.Sprintf("region %s (%d) failed at %s: %v", region, e.Tahun, e.Stage, e.Err)
+}
+
+func (e *ErrRegionFailed) Unwrap() error {
+	return e.Err
+}
+
+// ErrOutOfScope adalah error ketika API mengembalikan data di luar
+// kecamatan/desa yang diminta, misalnya karena filter kd_kec/kd_desa diabaikan.
+type ErrOutOfScope struct {
+	Requested     string
+	KodeKecamatan string
+	KodeDesa      string
+}
+
+func (e *ErrOutOfScope) Error() string {
+	return fmt.Sprintf("API returned kd_kec %s kd_desa %s outside requested unit %s", e.KodeKecamatan, e.KodeDesa, e.Requested)
+}
+
+// ErrSyncFailed adalah error gabungan ketika satu atau lebih wilayah gagal
+// disinkronkan. Errs berisi error per wilayah (biasanya *ErrRegionFailed).
+type ErrSyncFailed struct {
+	Total int
+	Errs  []error
+}
+
+func (e *ErrSyncFailed) Error() string {
+	return fmt.Sprintf("%d of %d regions failed to synchronize", len(e.Errs), e.Total)
+}
+
+func (e *ErrSyncFailed) Unwrap() []error {
+	return e.Errs
+}
+
+// ErrInvalidConfig adalah error ketika konfigurasi tidak valid. Semua masalah
+// dikumpulkan sekaligus agar bisa diperbaiki dalam satu kali jalan.
+type ErrInvalidConfig struct {
+	Problems []string
+}
+
+func (e *ErrInvalidConfig) Error() string {
+	return fmt.Sprintf("invalid configuration (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// ErrSchemaOutdated adalah error ketika skema database tertinggal dari
+// binary: Missing berisi migrasi yang belum diterapkan.
+type ErrSchemaOutdated struct {
+	Missing []string
+}
+
+func (e *ErrSchemaOutdated) Error() string {
+	return fmt.Sprintf("database schema is outdated, run 'migrate up' first; missing:\n  - %s", strings.Join(e.Missing, "\n  - "))
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// runExport menjalankan subcommand "export": menulis data yang tersimpan
+// untuk tahun yang dipilih ke CSV atau JSON Lines.
+func runExport(cfg *config.Config, logger *log.Logger, args []string) int {
+	fs := newFlagSet("export", "[flag]")
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (kosong berarti semua)")
+	formatPtr := fs.String("format", "csv", "Format output: csv atau jsonl")
+	outPtr := fs.String("out", "", "File output (kosong berarti stdout)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if *formatPtr != "csv" && *formatPtr != "jsonl" {
+		logging.Errorf(logger, "Error: format export tidak dikenal: %q (pilih csv atau jsonl)", *formatPtr)
+		return exitUsage
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not connect to database: %v", err)
+		return exitFatal
+	}
+	defer db.Close()
+
+	var out io.Writer = os.Stdout
+	if *outPtr != "" {
+		f, err := os.Create(*outPtr)
+		if err != nil {
+			logging.Errorf(logger, "FATAL: Could not create %s: %v", *outPtr, err)
+			return exitFatal
+		}
+		defer f.Close()
+		out = f
+	}
+	buffered := bufio.NewWriter(out)
+
+	write, flush := exportWriter(buffered, *formatPtr)
+	filter := storer.ExportFilter{
+		Tahun:        yearStrings(cfg.APIDataTahun),
+		KodeProvinsi: parseProvinsi(*provinsiPtr),
+	}
+	count := 0
+	err = newStorer(db, cfg, "", logger).ExportAnggaranDetails(context.Background(), filter, func(detail domain.AnggaranDetail) error {
+		count++
+		return write(detail)
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if err != nil {
+		logging.Errorf(logger, "ERROR: Export gagal setelah %d baris: %v", count, err)
+		return exitFatal
+	}
+
+	logging.Infof(logger, "%d baris diekspor.", count)
+	return exitOK
+}
+
+// exportWriter mengembalikan fungsi untuk menulis satu baris dalam format
+// yang dipilih, dan fungsi untuk menuntaskan output.
+func exportWriter(w io.Writer, format string) (write func(domain.AnggaranDetail) error, flush func() error) {
+	if format == "jsonl" {
+		enc := json.NewEncoder(w)
+		return func(d domain.AnggaranDetail) error { return enc.Encode(d) }, func() error { return nil }
+	}
+
+	// Header selalu ditulis, termasuk saat tidak ada baris; error tulis
+	// dari csv.Writer muncul kembali lewat cw.Error() di flush
+	cw := csv.NewWriter(w)
+	cw.Write(storer.ExportColumns())
+	write = func(d domain.AnggaranDetail) error {
+		return cw.Write(storer.ExportRecord(d))
+	}
+	flush = func() error {
+		cw.Flush()
+		return cw.Error()
+	}
+	return write, flush
+}
+
+// yearStrings mengubah daftar tahun menjadi string sesuai kolom tahun di tabel.
+func yearStrings(years []int) []string {
+	out := make([]string, len(years))
+	for i, y := range years {
+		out[i] = strconv.Itoa(y)
+	}
+	return out
+}
@@ -0,0 +1,59 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+)
+
+// statusAuthenticationTimeout adalah status non-standar (419) yang dipakai
+// backend Laravel ketika sesi atau token sudah kedaluwarsa.
+const statusAuthenticationTimeout = 419
+
+// tokenRefreshMargin adalah jarak sebelum token kedaluwarsa di mana fetcher
+// sudah melakukan login ulang secara proaktif.
+const tokenRefreshMargin = time.Minute
+
+// currentToken mengembalikan token yang sedang aktif.
+func (f *httpFetcher) currentToken() string {
+	f.authMu.Lock()
+	defer f.authMu.Unlock()
+	return f.authToken
+}
+
+// ensureToken melakukan login jika belum ada token atau token hampir kedaluwarsa.
+func (f *httpFetcher) ensureToken(ctx context.Context, logger *log.Logger) error {
+	f.authMu.Lock()
+	defer f.authMu.Unlock()
+
+	if f.authToken != "" && (f.tokenExpiry.IsZero() || time.Until(f.tokenExpiry) > tokenRefreshMargin) {
+		return nil
+	}
+	return f.authenticate(ctx, logger)
+}
+
+// refreshToken melakukan login ulang setelah staleToken ditolak server.
+// Jika pemanggil lain sudah memperbarui token selama kita menunggu lock,
+// login tidak diulang sehingga endpoint login tidak dibanjiri request.
+func (f *httpFetcher) refreshToken(ctx context.Context, staleToken string, logger *log.Logger) error {
+	f.authMu.Lock()
+	defer f.authMu.Unlock()
+
+	if f.authToken != "" && f.authToken != staleToken {
+		return nil
+	}
+	return f.authenticate(ctx, logger)
+}
+
+// isUnauthorized bernilai true jika API menolak token (401 atau 419).
+func isUnauthorized(err error) bool {
+	var apiErr *customErrors.ErrAPICallFailed
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == statusAuthenticationTimeout
+}
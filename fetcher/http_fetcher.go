@@ -4,17 +4,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/aryadiwwt/synctodb-anggarandetail/domain" // Ganti dengan domain Anda, misal: domain.AnggaranDetail
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
 )
 
 // Definisikan struct untuk menampung response dari API login
 type loginResponse struct {
 	Token string `json:"token"`
-	// Tambahkan field lain jika ada, misal: "user", "expires_in", dll.
+	// ExpiresIn adalah masa berlaku token dalam detik; 0 berarti tidak diketahui
+	ExpiresIn int `json:"expires_in"`
 }
 
 // Definisikan struct untuk request body login
@@ -28,114 +36,353 @@ type dataRequestBody struct {
 	Tahun  int    `json:"tahun"`
 	KdProv string `json:"kd_prov"`
 	KdKab  string `json:"kd_kab"`
+	KdKec  string `json:"kd_kec,omitempty"`
+	KdDesa string `json:"kd_desa,omitempty"`
 }
 
+// Query menentukan data yang diminta dari API. KdKec dan KdDesa opsional:
+// kosong berarti seluruh kabupaten atau seluruh kecamatan.
+type Query struct {
+	Tahun  int
+	KdProv string
+	KdKab  string
+	KdKec  string
+	KdDesa string
+	// Logger menerima log fetch dan retry untuk query ini, misalnya logger
+	// ber-prefix wilayah dari synchronizer; nil berarti logger standar
+	Logger *log.Logger
+}
+
+// logger mengembalikan Logger milik query atau logger standar jika kosong.
+func (q Query) logger() *log.Logger {
+	if q.Logger != nil {
+		return q.Logger
+	}
+	return log.Default()
+}
+
+// Page adalah satu halaman hasil fetch yang diserahkan ke PageHandler.
+type Page struct {
+	Number  int    // Nomor urut halaman, dimulai dari 1
+	URL     string // URL yang dipakai untuk mengambil halaman ini
+	Records []domain.AnggaranDetail
+}
+
+// PageHandler dipanggil untuk setiap halaman secara berurutan. Halaman berikutnya
+// baru diambil setelah handler selesai, sehingga handler yang lambat otomatis
+// menahan laju fetch. Error dari handler menghentikan streaming.
+type PageHandler func(ctx context.Context, page Page) error
+
 type Fetcher interface {
-	FetchAnggaranDetails(ctx context.Context, kdProv string, kdKab string) ([]domain.AnggaranDetail, error)
+	FetchAnggaranDetails(ctx context.Context, query Query) ([]domain.AnggaranDetail, error)
+	StreamAnggaranDetails(ctx context.Context, query Query, handle PageHandler) error
 }
 
 // httpFetcher sekarang memiliki state untuk token dan info login
 type httpFetcher struct {
-	client    *http.Client
-	dataURL   string
-	loginURL  string
-	username  string
-	password  string
-	authToken string // Tempat menyimpan token setelah login berhasil
-	tahun     int
+	client   *http.Client
+	dataURL  string
+	loginURL string
+	username string
+	password string
+	retry    RetryPolicy
+	// maxResponseBytes membatasi ukuran body response; <= 0 berarti tanpa batas
+	maxResponseBytes int64
+	// limiter membatasi laju semua request ke API; nil berarti tanpa batas
+	limiter *RateLimiter
+	// pageTimeout membatasi satu percobaan request halaman; <= 0 berarti tanpa batas
+	pageTimeout time.Duration
+
+	// authMu menjaga authToken dan tokenExpiry agar login tidak dijalankan
+	// bersamaan oleh beberapa pemanggil sekaligus
+	authMu      sync.Mutex
+	authToken   string    // Tempat menyimpan token setelah login berhasil
+	tokenExpiry time.Time // Zero value berarti masa berlaku tidak diketahui
 }
 
-// NewHTTPFetcher sekarang menerima konfigurasi login
-func NewHTTPFetcher(client *http.Client, dataURL, loginURL, username, password string, tahun int) Fetcher {
+// NewHTTPFetcher sekarang menerima konfigurasi login, retry policy, batas ukuran response,
+// rate limiter yang dipakai bersama oleh semua request dan batas waktu per request halaman.
+// Tahun anggaran dan wilayah diberikan per pemanggilan lewat Query sehingga satu
+// fetcher bisa dipakai untuk beberapa tahun.
+func NewHTTPFetcher(client *http.Client, dataURL, loginURL, username, password string, retry RetryPolicy, maxResponseBytes int64, limiter *RateLimiter, pageTimeout time.Duration) Fetcher {
 	return &httpFetcher{
-		client:   client,
-		dataURL:  dataURL,
-		loginURL: loginURL,
-		username: username,
-		password: password,
-		tahun:    tahun,
+		client:           client,
+		dataURL:          dataURL,
+		loginURL:         loginURL,
+		username:         username,
+		password:         password,
+		retry:            retry,
+		maxResponseBytes: maxResponseBytes,
+		limiter:          limiter,
+		pageTimeout:      pageTimeout,
 	}
 }
 
-func (f *httpFetcher) FetchAnggaranDetails(ctx context.Context, kdProv string, kdKab string) ([]domain.AnggaranDetail, error) {
-	// 1. Proses autentikasi hanya dilakukan sekali di awal
-	if f.authToken == "" {
-		if err := f.authenticate(ctx); err != nil {
-			return nil, fmt.Errorf("authentication failed: %w", err)
-		}
-	}
+// maxDrainBytes adalah batas body response error yang dibaca sebelum ditutup
+// (lihat drainBody).
+const maxDrainBytes = 64 << 10
+
+// pageResponse adalah isi satu halaman dari API yang sudah di-decode.
+type pageResponse struct {
+	Data        []domain.AnggaranDetail
+	NextPageURL string
+}
 
+// FetchAnggaranDetails mengumpulkan semua halaman ke dalam satu slice.
+// Untuk wilayah besar gunakan StreamAnggaranDetails agar data tidak ditahan di memori.
+func (f *httpFetcher) FetchAnggaranDetails(ctx context.Context, query Query) ([]domain.AnggaranDetail, error) {
 	// Slice untuk menampung hasil dari SEMUA halaman
 	var allData []domain.AnggaranDetail
 
-	// 2. Siapkan request body awal. Ini tidak akan berubah antar halaman.
+	err := f.StreamAnggaranDetails(ctx, query, func(_ context.Context, page Page) error {
+		allData = append(allData, page.Records...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logging.Infof(query.logger(), "Total %d records fetched from all pages.", len(allData))
+	return allData, nil
+}
+
+// StreamAnggaranDetails mengambil data halaman demi halaman dan menyerahkan
+// setiap halaman ke handle segera setelah diterima.
+func (f *httpFetcher) StreamAnggaranDetails(ctx context.Context, query Query, handle PageHandler) error {
+	// 1. Siapkan request body awal. Ini tidak akan berubah antar halaman.
 	dataPayload := dataRequestBody{
-		Tahun:  f.tahun,
-		KdProv: kdProv,
-		KdKab:  kdKab,
+		Tahun:  query.Tahun,
+		KdProv: query.KdProv,
+		KdKab:  query.KdKab,
+		KdKec:  query.KdKec,
+		KdDesa: query.KdDesa,
 	}
 	body, err := json.Marshal(dataPayload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal data request body: %w", err)
+		return fmt.Errorf("failed to marshal data request body: %w", err)
 	}
 
-	// 3. Mulai loop dari URL data utama
+	// 2. Mulai loop dari URL data utama. Token diperiksa di setiap halaman
+	// (lihat fetchPageWithRetry) karena streaming wilayah besar bisa lebih lama
+	// dari masa berlaku token
 	nextPageURL := f.dataURL
 
-	for nextPageURL != "" { // Lakukan loop selama masih ada halaman berikutnya
-		// Gunakan bytes.NewReader agar body bisa dibaca berulang kali di setiap loop
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, nextPageURL, bytes.NewReader(body))
+	for pageNumber := 1; nextPageURL != ""; pageNumber++ { // Lakukan loop selama masih ada halaman berikutnya
+		// Berhenti segera jika context sudah dibatalkan di antara halaman
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		resp, err := f.fetchPageWithRetry(ctx, nextPageURL, body, query.logger())
 		if err != nil {
-			return nil, fmt.Errorf("failed to create request for page %s: %w", nextPageURL, err)
+			return err
 		}
 
-		req.Header.Set("Authorization", "Bearer "+f.authToken)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
+		page := Page{Number: pageNumber, URL: nextPageURL, Records: resp.Data}
+		if err := handle(ctx, page); err != nil {
+			return err
+		}
 
-		log.Printf("Fetching data from: %s", nextPageURL)
+		// Perbarui URL untuk iterasi selanjutnya, kosong berarti loop berhenti
+		nextPageURL = resp.NextPageURL
+	}
 
-		resp, err := f.client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to execute request for page %s: %w", nextPageURL, err)
+	return nil
+}
+
+// fetchPageWithRetry memanggil fetchPage dan mengulanginya sesuai retry policy.
+// Jeda mengikuti exponential backoff dengan jitter, atau header Retry-After jika ada.
+func (f *httpFetcher) fetchPageWithRetry(ctx context.Context, pageURL string, body []byte, logger *log.Logger) (*pageResponse, error) {
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		// Pastikan token tersedia dan belum (hampir) kedaluwarsa sebelum setiap request
+		if err := f.ensureToken(ctx, logger); err != nil {
+			return nil, fmt.Errorf("authentication failed: %w", err)
+		}
+		token := f.currentToken()
+		page, err := f.fetchPage(ctx, pageURL, body, token, logger)
+		if err == nil {
+			return page, nil
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status code %d on page %s", resp.StatusCode, nextPageURL)
+		// Token ditolak: login ulang sekali lalu ulangi halaman yang sama
+		// tanpa menghitungnya sebagai percobaan gagal
+		if isUnauthorized(err) && !reauthenticated {
+			reauthenticated = true
+			logging.Warnf(logger, "Token rejected on page %s, re-authenticating...", pageURL)
+			if err := f.refreshToken(ctx, token, logger); err != nil {
+				return nil, fmt.Errorf("re-authentication failed: %w", err)
+			}
+			attempt--
+			continue
 		}
 
-		// Definisikan struct yang cocok dengan respons API yang kompleks
-		type PaginatedData struct {
-			Data        []domain.AnggaranDetail `json:"data"`          // Array data yang kita inginkan
-			NextPageURL *string                 `json:"next_page_url"` // Pointer agar bisa null
+		if err := f.waitForRetry(ctx, err, attempt, "page "+pageURL, logger); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForRetry memutuskan apakah percobaan ke-attempt yang gagal dengan err
+// layak diulang. Jika ya, waitForRetry menunggu jeda retry lalu mengembalikan
+// nil; jika tidak, error yang harus dikembalikan pemanggil. target hanya
+// dipakai untuk log dan pesan error, misal "page <url>" atau "login".
+func (f *httpFetcher) waitForRetry(ctx context.Context, err error, attempt int, target string, logger *log.Logger) error {
+	maxAttempts := f.retry.attempts()
+	retryable, delay := f.shouldRetry(err, attempt)
+	if !retryable || attempt >= maxAttempts {
+		return err
+	}
+	// Jangan menunggu jika jeda akan melewati deadline context
+	if exceedsDeadline(ctx, delay) {
+		return fmt.Errorf("retry for %s abandoned, deadline too close: %w", target, err)
+	}
+
+	logging.Warnf(logger, "Attempt %d/%d for %s failed: %v. Retrying in %s...", attempt, maxAttempts, target, err, delay.Round(time.Millisecond))
+	if err := sleepContext(ctx, delay); err != nil {
+		return fmt.Errorf("retry for %s cancelled: %w", target, err)
+	}
+	return nil
+}
+
+// shouldRetry menentukan apakah error layak diulang dan berapa lama harus menunggu.
+func (f *httpFetcher) shouldRetry(err error, attempt int) (bool, time.Duration) {
+	// Context yang dibatalkan atau habis waktunya tidak perlu diulang
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false, 0
+	}
+
+	var apiErr *customErrors.ErrAPICallFailed
+	if errors.As(err, &apiErr) {
+		if !f.retry.isRetryableStatus(apiErr.StatusCode) {
+			return false, 0
 		}
-		type ApiResponse struct {
-			Data PaginatedData `json:"data"`
+		if apiErr.RetryAfter > 0 {
+			// Retry-After tetap dibatasi MaxDelay agar server tidak bisa menahan
+			// worker lebih lama dari yang diizinkan retry policy
+			return true, f.retry.capDelay(apiErr.RetryAfter)
 		}
+		return true, f.retry.backoff(attempt)
+	}
 
-		var fullResponse ApiResponse
-		if err := json.NewDecoder(resp.Body).Decode(&fullResponse); err != nil {
-			return nil, fmt.Errorf("failed to decode api response for page %s: %w", nextPageURL, err)
+	// Error jaringan (koneksi putus, timeout, body terpotong, dsb.) dianggap sementara
+	var netErr net.Error
+	var truncErr *customErrors.ErrResponseTruncated
+	var timeoutErr *customErrors.ErrPageTimeout
+	if errors.As(err, &netErr) || errors.As(err, &truncErr) || errors.As(err, &timeoutErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, f.retry.backoff(attempt)
+	}
+	return false, 0
+}
+
+// fetchPage melakukan satu percobaan request halaman dengan batas waktu pageTimeout.
+// Waktu tunggu rate limiter tidak dihitung ke dalam batas waktu tersebut.
+func (f *httpFetcher) fetchPage(ctx context.Context, pageURL string, body []byte, token string, logger *log.Logger) (*pageResponse, error) {
+	// Tunggu giliran dari rate limiter sebelum mengirim request
+	if err := f.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter wait for page %s: %w", pageURL, err)
+	}
+
+	if f.pageTimeout <= 0 {
+		return f.requestPage(ctx, pageURL, body, token, logger)
+	}
+	pageCtx, cancel := context.WithTimeout(ctx, f.pageTimeout)
+	defer cancel()
+
+	page, err := f.requestPage(pageCtx, pageURL, body, token, logger)
+	// Hanya deadline halaman yang habis: laporkan sebagai timeout yang bisa diulang,
+	// bukan context.DeadlineExceeded yang menghentikan retry
+	if err != nil && ctx.Err() == nil && errors.Is(pageCtx.Err(), context.DeadlineExceeded) {
+		return nil, &customErrors.ErrPageTimeout{URL: pageURL, Timeout: f.pageTimeout}
+	}
+	return page, err
+}
+
+// requestPage mengirim request untuk satu halaman dan menutup body-nya sebelum kembali.
+func (f *httpFetcher) requestPage(ctx context.Context, pageURL string, body []byte, token string, logger *log.Logger) (*pageResponse, error) {
+	// Gunakan bytes.NewReader agar body bisa dibaca berulang kali di setiap percobaan
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for page %s: %w", pageURL, err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	logging.Infof(logger, "Fetching data from: %s", pageURL)
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request for page %s: %w", pageURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		drainBody(resp)
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, &customErrors.ErrAPICallFailed{
+			StatusCode: resp.StatusCode,
+			Message:    fmt.Sprintf("unexpected status code on page %s", pageURL),
+			RetryAfter: retryAfter,
 		}
+	}
+
+	// Definisikan struct yang cocok dengan respons API yang kompleks
+	type PaginatedData struct {
+		Data        []domain.AnggaranDetail `json:"data"`          // Array data yang kita inginkan
+		NextPageURL *string                 `json:"next_page_url"` // Pointer agar bisa null
+	}
+	type ApiResponse struct {
+		Data PaginatedData `json:"data"`
+	}
 
-		// Tambahkan hasil dari halaman ini ke slice utama
-		allData = append(allData, fullResponse.Data.Data...)
+	data, err := readBody(resp, pageURL, f.maxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
 
-		// Perbarui URL untuk iterasi selanjutnya, atau hentikan loop
-		if fullResponse.Data.NextPageURL != nil {
-			nextPageURL = *fullResponse.Data.NextPageURL
-		} else {
-			nextPageURL = "" // Hentikan loop jika next_page_url adalah null
+	var fullResponse ApiResponse
+	if err := json.Unmarshal(data, &fullResponse); err != nil {
+		if isTruncatedJSON(err, data) {
+			return nil, &customErrors.ErrResponseTruncated{URL: pageURL, Err: err}
 		}
+		return nil, fmt.Errorf("failed to decode api response for page %s: %w", pageURL, err)
 	}
 
-	log.Printf("Total %d records fetched from all pages.", len(allData))
-	return allData, nil
+	page := &pageResponse{Data: fullResponse.Data.Data}
+	if fullResponse.Data.NextPageURL != nil {
+		page.NextPageURL = *fullResponse.Data.NextPageURL
+	}
+	return page, nil
+}
+
+// authenticate adalah fungsi internal untuk login dan menyimpan token. Login
+// diulang dengan retry policy yang sama seperti request halaman, sehingga
+// 429/5xx sesaat di endpoint login tidak menggagalkan wilayah.
+// Pemanggil wajib memegang authMu. Log ditulis ke logger milik query yang
+// memicu login.
+func (f *httpFetcher) authenticate(ctx context.Context, logger *log.Logger) error {
+	for attempt := 1; ; attempt++ {
+		lr, err := f.login(ctx)
+		if err == nil {
+			// Simpan token untuk request selanjutnya
+			f.authToken = lr.Token
+			f.tokenExpiry = time.Time{}
+			if lr.ExpiresIn > 0 {
+				f.tokenExpiry = time.Now().Add(time.Duration(lr.ExpiresIn) * time.Second)
+			}
+			logging.Infof(logger, "Successfully authenticated and obtained token.")
+			return nil
+		}
+		if err := f.waitForRetry(ctx, err, attempt, "login", logger); err != nil {
+			return err
+		}
+	}
 }
 
-// authenticate adalah fungsi internal untuk login dan menyimpan token.
-func (f *httpFetcher) authenticate(ctx context.Context) error {
+// login melakukan satu percobaan login dan mengembalikan token dari server.
+func (f *httpFetcher) login(ctx context.Context) (*loginResponse, error) {
 	loginPayload := loginRequest{
 		Username: f.username,
 		Password: f.password,
@@ -143,36 +390,50 @@ func (f *httpFetcher) authenticate(ctx context.Context) error {
 
 	body, err := json.Marshal(loginPayload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal login request: %w", err)
+		return nil, fmt.Errorf("failed to marshal login request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.loginURL, bytes.NewBuffer(body))
 	if err != nil {
-		return fmt.Errorf("failed to create login request: %w", err)
+		return nil, fmt.Errorf("failed to create login request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
+	if err := f.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter wait for login: %w", err)
+	}
+
 	resp, err := f.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute login request: %w", err)
+		return nil, fmt.Errorf("failed to execute login request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("login failed with status code: %d", resp.StatusCode)
+		drainBody(resp)
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		return nil, &customErrors.ErrAPICallFailed{
+			StatusCode: resp.StatusCode,
+			Message:    "login failed",
+			RetryAfter: retryAfter,
+		}
+	}
+
+	data, err := readBody(resp, f.loginURL, f.maxResponseBytes)
+	if err != nil {
+		return nil, err
 	}
 
 	var lr loginResponse
-	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
-		return fmt.Errorf("failed to decode login response: %w", err)
+	if err := json.Unmarshal(data, &lr); err != nil {
+		if isTruncatedJSON(err, data) {
+			return nil, &customErrors.ErrResponseTruncated{URL: f.loginURL, Err: err}
+		}
+		return nil, fmt.Errorf("failed to decode login response: %w", err)
 	}
 
 	if lr.Token == "" {
-		return fmt.Errorf("login successful but token is empty")
+		return nil, fmt.Errorf("login successful but token is empty")
 	}
-
-	// Simpan token untuk request selanjutnya
-	f.authToken = lr.Token
-	fmt.Println("Successfully authenticated and obtained token.") // Ganti dengan logger
-	return nil
+	return &lr, nil
 }
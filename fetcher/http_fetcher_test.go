@@ -0,0 +1,405 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+)
+
+// testPolicy memakai jeda sangat pendek agar test tidak menunggu backoff sungguhan.
+var testPolicy = RetryPolicy{
+	MaxAttempts:     3,
+	BaseDelay:       time.Millisecond,
+	MaxDelay:        10 * time.Second,
+	RetryableStatus: []int{429, 502, 503, 504},
+}
+
+// apiStub adalah pengganti API: endpoint /login selalu berhasil, sedangkan
+// endpoint /data menjawab dengan respond untuk setiap percobaan (dimulai dari 1).
+type apiStub struct {
+	server   *httptest.Server
+	attempts atomic.Int32
+	logins   atomic.Int32
+}
+
+func newAPIStub(t *testing.T, respond func(w http.ResponseWriter, attempt int)) *apiStub {
+	t.Helper()
+	return newAuthStub(t, writeToken, func(w http.ResponseWriter, _ *http.Request, attempt int) {
+		respond(w, attempt)
+	})
+}
+
+// newAuthStub seperti newAPIStub, tetapi /login dijawab oleh login untuk setiap
+// login (dimulai dari 1) dan respond menerima request agar bisa memeriksa token.
+func newAuthStub(t *testing.T, login func(w http.ResponseWriter, n int), respond func(w http.ResponseWriter, r *http.Request, attempt int)) *apiStub {
+	t.Helper()
+	stub := &apiStub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		login(w, int(stub.logins.Add(1)))
+	})
+	mux.HandleFunc("/data", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, int(stub.attempts.Add(1)))
+	})
+	stub.server = httptest.NewServer(mux)
+	t.Cleanup(stub.server.Close)
+	return stub
+}
+
+// writeToken menjawab login ke-n dengan token "token-<n>" yang berlaku satu jam.
+func writeToken(w http.ResponseWriter, n int) {
+	fmt.Fprintf(w, `{"token":"token-%d","expires_in":3600}`, n)
+}
+
+func (s *apiStub) fetcher(policy RetryPolicy) Fetcher {
+	return NewHTTPFetcher(s.server.Client(), s.server.URL+"/data", s.server.URL+"/login", "user", "pass", policy, 0, nil, 0)
+}
+
+// writePage menulis satu halaman terakhir berisi satu record.
+func writePage(w http.ResponseWriter) {
+	fmt.Fprint(w, `{"data":{"data":[{"tahun":"2024","kd_prov":"51","kd_kab":"03"}],"next_page_url":null}}`)
+}
+
+// failThenSucceed menjawab status pada percobaan pertama lalu halaman sukses.
+func failThenSucceed(status int, retryAfter string) func(w http.ResponseWriter, attempt int) {
+	return func(w http.ResponseWriter, attempt int) {
+		if attempt == 1 {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"message":"try again"}`)
+			return
+		}
+		writePage(w)
+	}
+}
+
+var testQuery = Query{Tahun: 2024, KdProv: "51", KdKab: "03"}
+
+func TestFetchRetriesRetryableStatus(t *testing.T) {
+	for _, status := range []int{429, 502, 503, 504} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			stub := newAPIStub(t, failThenSucceed(status, ""))
+
+			records, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery)
+			if err != nil {
+				t.Fatalf("FetchAnggaranDetails: %v", err)
+			}
+			if len(records) != 1 {
+				t.Errorf("got %d records, want 1", len(records))
+			}
+			if got := stub.attempts.Load(); got != 2 {
+				t.Errorf("got %d attempts, want 2", got)
+			}
+		})
+	}
+}
+
+func TestFetchHonoursRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter func() string
+		minWait    time.Duration
+	}{
+		{"seconds", func() string { return "1" }, 900 * time.Millisecond},
+		// HTTP-date hanya berresolusi detik, jadi jeda sebenarnya antara 1 dan 2 detik
+		{"http-date", func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }, 900 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			stub := newAPIStub(t, failThenSucceed(http.StatusTooManyRequests, tt.retryAfter()))
+
+			start := time.Now()
+			if _, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery); err != nil {
+				t.Fatalf("FetchAnggaranDetails: %v", err)
+			}
+			if waited := time.Since(start); waited < tt.minWait {
+				t.Errorf("retried after %s, want at least %s", waited, tt.minWait)
+			}
+			if got := stub.attempts.Load(); got != 2 {
+				t.Errorf("got %d attempts, want 2", got)
+			}
+		})
+	}
+}
+
+func TestFetchCapsRetryAfterAtMaxDelay(t *testing.T) {
+	stub := newAPIStub(t, failThenSucceed(http.StatusServiceUnavailable, "3600"))
+	policy := testPolicy
+	policy.MaxDelay = 20 * time.Millisecond
+
+	start := time.Now()
+	if _, err := stub.fetcher(policy).FetchAnggaranDetails(context.Background(), testQuery); err != nil {
+		t.Fatalf("FetchAnggaranDetails: %v", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("retried after %s, want Retry-After capped at %s", waited, policy.MaxDelay)
+	}
+}
+
+func TestFetchGivesUpWhenDelayPassesDeadline(t *testing.T) {
+	stub := newAPIStub(t, failThenSucceed(http.StatusServiceUnavailable, "60"))
+	policy := testPolicy
+	policy.MaxDelay = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := stub.fetcher(policy).FetchAnggaranDetails(ctx, testQuery)
+	if err == nil || !strings.Contains(err.Error(), "deadline too close") {
+		t.Fatalf("got error %v, want retry abandoned before the deadline", err)
+	}
+	var apiErr *customErrors.ErrAPICallFailed
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("got error %v, want wrapped 503 ErrAPICallFailed", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("gave up after %s, want immediately", waited)
+	}
+	if got := stub.attempts.Load(); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestFetchDoesNotRetryClientError(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusUnprocessableEntity} {
+		t.Run(fmt.Sprint(status), func(t *testing.T) {
+			stub := newAPIStub(t, failThenSucceed(status, "1"))
+
+			_, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery)
+			var apiErr *customErrors.ErrAPICallFailed
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != status {
+				t.Fatalf("got error %v, want ErrAPICallFailed with status %d", err, status)
+			}
+			if got := stub.attempts.Load(); got != 1 {
+				t.Errorf("got %d attempts, want 1", got)
+			}
+		})
+	}
+}
+
+func TestLoginRetriesRetryableStatus(t *testing.T) {
+	stub := newAuthStub(t, func(w http.ResponseWriter, n int) {
+		if n == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		writeToken(w, n)
+	}, func(w http.ResponseWriter, _ *http.Request, _ int) { writePage(w) })
+
+	if _, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery); err != nil {
+		t.Fatalf("FetchAnggaranDetails: %v", err)
+	}
+	if got := stub.logins.Load(); got != 2 {
+		t.Errorf("got %d logins, want 2", got)
+	}
+}
+
+func TestLoginDoesNotRetryRejectedCredentials(t *testing.T) {
+	stub := newAuthStub(t, func(w http.ResponseWriter, _ int) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}, func(w http.ResponseWriter, _ *http.Request, _ int) { writePage(w) })
+
+	_, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery)
+	var apiErr *customErrors.ErrAPICallFailed
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("got error %v, want ErrAPICallFailed with status 401", err)
+	}
+	if got := stub.logins.Load(); got != 1 {
+		t.Errorf("got %d logins, want 1", got)
+	}
+	if got := stub.attempts.Load(); got != 0 {
+		t.Errorf("got %d data requests, want 0", got)
+	}
+}
+
+func TestFetchReplaysPageAfterTokenRejected(t *testing.T) {
+	var stub *apiStub
+	stub = newAuthStub(t, writeToken, func(w http.ResponseWriter, r *http.Request, _ int) {
+		if r.URL.Query().Get("page") != "2" {
+			fmt.Fprintf(w, `{"data":{"data":[{"tahun":"2024","kd_prov":"51","kd_kab":"03"}],"next_page_url":%q}}`, stub.server.URL+"/data?page=2")
+			return
+		}
+		// Token pertama dicabut server di tengah streaming
+		if r.Header.Get("Authorization") != "Bearer token-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writePage(w)
+	})
+
+	var pages []string
+	err := stub.fetcher(testPolicy).StreamAnggaranDetails(context.Background(), testQuery, func(_ context.Context, page Page) error {
+		pages = append(pages, page.URL)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamAnggaranDetails: %v", err)
+	}
+	want := []string{stub.server.URL + "/data", stub.server.URL + "/data?page=2"}
+	if !slices.Equal(pages, want) {
+		t.Errorf("got pages %q, want %q", pages, want)
+	}
+	if got := stub.logins.Load(); got != 2 {
+		t.Errorf("got %d logins, want 2", got)
+	}
+	// Halaman 1, halaman 2 yang ditolak, lalu halaman 2 diulang dengan token baru
+	if got := stub.attempts.Load(); got != 3 {
+		t.Errorf("got %d data requests, want 3", got)
+	}
+}
+
+func TestConcurrentTokenRejectionReauthenticatesOnce(t *testing.T) {
+	const callers = 8
+
+	// Semua pemanggil harus mengirim token pertama sebelum server menolaknya,
+	// sehingga setiap pemanggil menerima 401 dan mencoba refreshToken
+	var rejected atomic.Int32
+	allRejected := make(chan struct{})
+	stub := newAuthStub(t, writeToken, func(w http.ResponseWriter, r *http.Request, _ int) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			if rejected.Add(1) == callers {
+				close(allRejected)
+			}
+			select {
+			case <-allRejected:
+			case <-time.After(5 * time.Second):
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writePage(w)
+	})
+	f := stub.fetcher(testPolicy)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := f.FetchAnggaranDetails(context.Background(), testQuery)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("FetchAnggaranDetails: %v", err)
+		}
+	}
+	if got := rejected.Load(); got != callers {
+		t.Errorf("got %d rejected requests, want %d", got, callers)
+	}
+	// Login awal ditambah tepat satu login ulang untuk semua pemanggil
+	if got := stub.logins.Load(); got != 2 {
+		t.Errorf("got %d logins, want 2", got)
+	}
+}
+
+func TestFetchRefreshesTokenBeforeExpiry(t *testing.T) {
+	var stub *apiStub
+	stub = newAuthStub(t, func(w http.ResponseWriter, n int) {
+		// Masa berlaku di bawah tokenRefreshMargin: setiap halaman butuh login baru
+		fmt.Fprintf(w, `{"token":"token-%d","expires_in":30}`, n)
+	}, func(w http.ResponseWriter, r *http.Request, attempt int) {
+		if attempt == 1 {
+			fmt.Fprintf(w, `{"data":{"data":[],"next_page_url":%q}}`, stub.server.URL+"/data?page=2")
+			return
+		}
+		writePage(w)
+	})
+
+	if _, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery); err != nil {
+		t.Fatalf("FetchAnggaranDetails: %v", err)
+	}
+	if got := stub.logins.Load(); got != 2 {
+		t.Errorf("got %d logins, want 2", got)
+	}
+}
+
+// writeTruncated menulis response 200 tanpa Content-Length lalu menutup
+// koneksi di tengah JSON. Bagi klien body berakhir normal (EOF), sehingga
+// pemotongan hanya terlihat saat decode.
+func writeTruncated(t *testing.T, w http.ResponseWriter) {
+	conn, buf, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		t.Errorf("hijack: %v", err)
+		return
+	}
+	defer conn.Close()
+	fmt.Fprint(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/json\r\nConnection: close\r\n\r\n")
+	fmt.Fprint(buf, `{"data":{"data":[{"tahun":"2024","kd_pr`)
+	buf.Flush()
+}
+
+func TestFetchRetriesTruncatedBody(t *testing.T) {
+	stub := newAPIStub(t, func(w http.ResponseWriter, attempt int) {
+		if attempt == 1 {
+			writeTruncated(t, w)
+			return
+		}
+		writePage(w)
+	})
+
+	records, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery)
+	if err != nil {
+		t.Fatalf("FetchAnggaranDetails: %v", err)
+	}
+	if len(records) != 1 {
+		t.Errorf("got %d records, want 1", len(records))
+	}
+	if got := stub.attempts.Load(); got != 2 {
+		t.Errorf("got %d attempts, want 2", got)
+	}
+}
+
+func TestFetchReportsTruncatedBody(t *testing.T) {
+	stub := newAPIStub(t, func(w http.ResponseWriter, attempt int) { writeTruncated(t, w) })
+
+	_, err := stub.fetcher(testPolicy).FetchAnggaranDetails(context.Background(), testQuery)
+	var truncErr *customErrors.ErrResponseTruncated
+	if !errors.As(err, &truncErr) {
+		t.Fatalf("got error %v, want ErrResponseTruncated", err)
+	}
+	if got := stub.attempts.Load(); got != int32(testPolicy.MaxAttempts) {
+		t.Errorf("got %d attempts, want %d", got, testPolicy.MaxAttempts)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"30", 30 * time.Second, true},
+		{"-1", 0, false},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, true},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseRetryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package fetcher
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter adalah token bucket sederhana yang dipakai bersama oleh semua
+// request ke API (login maupun halaman data). Token bertambah sebanyak rate
+// per detik hingga maksimal burst.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // token per detik
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter membuat limiter dengan laju rps request per detik dan kapasitas burst.
+// rps <= 0 menghasilkan nil, yang berarti tanpa pembatasan.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rate:   rps,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait menunggu hingga satu token tersedia atau context dibatalkan.
+// Limiter nil tidak pernah menunggu.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if exceedsDeadline(ctx, delay) {
+			return context.DeadlineExceeded
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve mengambil satu token jika tersedia dan mengembalikan 0, atau
+// mengembalikan lama waktu sampai token berikutnya tersedia.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0
+	}
+	return time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+}
@@ -0,0 +1,58 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+)
+
+// readBody membaca seluruh body response dengan batas ukuran maksimum.
+// Body yang melebihi batas menghasilkan ErrResponseTooLarge, sedangkan body yang
+// lebih pendek dari Content-Length atau terputus di tengah jalan menghasilkan
+// ErrResponseTruncated. maxBytes <= 0 berarti tanpa batas.
+func readBody(resp *http.Response, url string, maxBytes int64) ([]byte, error) {
+	// Tolak lebih awal jika server sudah mengumumkan ukuran yang terlalu besar
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return nil, &customErrors.ErrResponseTooLarge{URL: url, Limit: maxBytes}
+	}
+
+	var reader io.Reader = resp.Body
+	if maxBytes > 0 {
+		// Baca satu byte lebih agar bisa membedakan "tepat di batas" dan "melebihi batas"
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, &customErrors.ErrResponseTruncated{URL: url, Err: err}
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, &customErrors.ErrResponseTooLarge{URL: url, Limit: maxBytes}
+	}
+	if resp.ContentLength >= 0 && int64(len(data)) != resp.ContentLength {
+		return nil, &customErrors.ErrResponseTruncated{URL: url, Err: io.ErrUnexpectedEOF}
+	}
+	return data, nil
+}
+
+// isTruncatedJSON bernilai true jika error decode JSON disebabkan data yang
+// berhenti di tengah. json.Unmarshal melaporkannya sebagai *json.SyntaxError
+// ("unexpected end of JSON input") dengan Offset tepat di akhir data, misalnya
+// ketika koneksi ditutup sebelum body selesai pada response tanpa Content-Length.
+func isTruncatedJSON(err error, data []byte) bool {
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) && syntaxErr.Offset == int64(len(data)) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// drainBody membaca sisa body (dibatasi maxDrainBytes) sebelum ditutup agar
+// koneksi keep-alive bisa dipakai ulang. Body yang lebih besar dibiarkan dan
+// koneksinya tidak dipakai ulang.
+func drainBody(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+}
@@ -0,0 +1,100 @@
+package fetcher
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy mengatur berapa kali dan seberapa lama fetcher menunggu
+// sebelum mengulang request halaman atau login yang gagal.
+type RetryPolicy struct {
+	MaxAttempts     int           // Total percobaan, termasuk percobaan pertama
+	BaseDelay       time.Duration // Jeda awal, dikali dua setiap percobaan
+	MaxDelay        time.Duration // Batas atas jeda antar percobaan
+	Jitter          float64       // Fraksi acak (0..1) yang ditambahkan/dikurangi dari jeda
+	RetryableStatus []int         // Status HTTP yang layak diulang, misal 429/502/503/504
+}
+
+// attempts mengembalikan MaxAttempts, minimal satu percobaan.
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+func (p RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatus {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff menghitung jeda untuk percobaan ke-n (dimulai dari 1) dengan jitter.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		// Sebar jeda secara acak di rentang [delay*(1-jitter), delay*(1+jitter)]
+		delay += delay * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// capDelay membatasi d pada MaxDelay; MaxDelay <= 0 berarti tanpa batas.
+func (p RetryPolicy) capDelay(d time.Duration) time.Duration {
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// parseRetryAfter membaca header Retry-After dalam format detik atau HTTP-date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := t.Sub(now)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// sleepContext menunggu selama d, atau berhenti lebih awal jika context dibatalkan.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// exceedsDeadline bernilai true jika menunggu selama d akan melewati deadline context.
+func exceedsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(d).After(deadline)
+}
@@ -0,0 +1,80 @@
+// Package logging menambahkan level pada logger standar. Setiap baris log
+// ditulis lewat Infof, Warnf atau Errorf sehingga levelnya ditentukan oleh
+// pemanggil, dan baris di bawah level minimum (SetLevel) tidak ditulis.
+package logging
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level log, dari yang paling rinci.
+type Level int32
+
+const (
+	LevelInfo Level = iota
+	LevelWarn
+	LevelError
+)
+
+// minLevel berlaku untuk seluruh proses, seperti flag pada logger standar.
+var minLevel atomic.Int32
+
+// ParseLevel mengubah nilai flag -log-level menjadi Level.
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToLower(value) {
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return 0, fmt.Errorf("log level tidak dikenal: %q (pilih info, warn atau error)", value)
+	}
+}
+
+// SetLevel mengatur level minimum yang ditulis.
+func SetLevel(level Level) {
+	minLevel.Store(int32(level))
+}
+
+// Enabled bernilai true jika baris dengan level ini akan ditulis.
+func Enabled(level Level) bool {
+	return int32(level) >= minLevel.Load()
+}
+
+// Logf menulis satu baris ke logger jika level tidak di bawah level minimum.
+// Logger nil berarti logger standar.
+func Logf(logger *log.Logger, level Level, format string, args ...any) {
+	logf(logger, level, format, args...)
+}
+
+// Infof menulis baris informasi, misalnya progres sinkronisasi.
+func Infof(logger *log.Logger, format string, args ...any) {
+	logf(logger, LevelInfo, format, args...)
+}
+
+// Warnf menulis baris peringatan: ada yang tidak beres, tapi proses tetap berjalan.
+func Warnf(logger *log.Logger, format string, args ...any) {
+	logf(logger, LevelWarn, format, args...)
+}
+
+// Errorf menulis baris error: operasi atau wilayah gagal.
+func Errorf(logger *log.Logger, format string, args ...any) {
+	logf(logger, LevelError, format, args...)
+}
+
+// logf dipanggil tepat satu tingkat di bawah fungsi publik agar calldepth
+// menunjuk ke pemanggil asli saat logger memakai Lshortfile.
+func logf(logger *log.Logger, level Level, format string, args ...any) {
+	if !Enabled(level) {
+		return
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Output(3, fmt.Sprintf(format, args...))
+}
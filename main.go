@@ -1,118 +1,338 @@
 package main
 
 import (
-	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"math/rand"
 	"net/http"
 	"os"
-	"strconv"
+	"runtime/debug"
 	"strings"
 	"time"
 
 	"github.com/aryadiwwt/synctodb-anggarandetail/config"
 	"github.com/aryadiwwt/synctodb-anggarandetail/fetcher"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
 	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
-	"github.com/aryadiwwt/synctodb-anggarandetail/synchronizer"
+	"github.com/aryadiwwt/synctodb-anggarandetail/wilayah"
 	"github.com/joho/godotenv"
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 )
 
+// version diisi saat build, misal:
+// go build -ldflags "-X main.version=$(git describe --tags --always)"
+var version = ""
+
+const programName = "synctodb-anggarandetail"
+
+// Exit code proses agar scheduler bisa membedakan jenis kegagalan.
+const (
+	exitOK            = 0
+	exitFatal         = 1 // Setup gagal atau daftar wilayah tidak bisa dibaca
+	exitUsage         = 2 // Argumen command line tidak valid
+	exitRegionsFailed = 3 // Sinkronisasi selesai tetapi ada wilayah yang gagal (atau verify menemukan selisih)
+	exitInterrupted   = 4 // Dihentikan oleh SIGINT/SIGTERM setelah wilayah berjalan selesai
+	exitForced        = 5 // Dihentikan paksa oleh sinyal kedua
+)
+
+// command adalah satu subcommand beserta fungsi yang menjalankannya.
+type command struct {
+	name    string
+	summary string
+	run     func(cfg *config.Config, logger *log.Logger, args []string) int
+	// logToStderr memindahkan log ke stderr karena stdout dipakai untuk data
+	logToStderr bool
+	// skipValidate menjalankan subcommand meskipun konfigurasi tidak valid
+	skipValidate bool
+}
+
+// defaultCommand dijalankan jika tidak ada subcommand, sama dengan perilaku
+// sebelum CLI dipecah menjadi subcommand (misal jadwal cron lama).
+const defaultCommand = "sync"
+
+var commands = []command{
+	{name: "sync", summary: "Sinkronkan data anggaran dari API ke database", run: runSync},
+	{name: "status", summary: "Tampilkan checkpoint per wilayah dan run terakhir", run: runStatus},
+	{name: "verify", summary: "Bandingkan jumlah baris dan total nilai di database dengan API", run: runVerify},
+	{name: "export", summary: "Ekspor data yang tersimpan ke CSV atau JSON Lines", run: runExport, logToStderr: true},
+	{name: "migrate", summary: "Terapkan atau batalkan migrasi skema database", run: runMigrate},
+	{name: "config", summary: "Tampilkan atau periksa konfigurasi efektif", run: runConfig, skipValidate: true},
+}
+
 func main() {
+	os.Exit(run())
+}
+
+// run membaca flag global, memuat konfigurasi lalu menjalankan subcommand
+// dan mengembalikan exit code, sehingga semua defer (misal db.Close) tetap
+// dijalankan sebelum os.Exit.
+func run() int {
+	global := flag.NewFlagSet(programName, flag.ContinueOnError)
+	configPath := global.String("config", "", "File konfigurasi TOML; env var dan flag menimpa nilainya")
+	logLevel := global.String("log-level", "info", "Level log minimum: info, warn atau error")
+	tahun := global.String("tahun", "", "Tahun anggaran atau rentang tahun (contoh: 2023-2025,2027), menimpa API_DATA_TAHUN")
+	global.Usage = func() { printUsage(global) }
+	if code, ok := parseFlags(global, withDefaultCommand(global, os.Args[1:])); !ok {
+		return code
+	}
+
+	cmd, ok := findCommand(global.Arg(0))
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: subcommand tidak dikenal: %s\n\n", global.Arg(0))
+		global.Usage()
+		return exitUsage
+	}
+
+	level, err := logging.ParseLevel(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	// Level berlaku untuk semua paket yang menulis log lewat paket logging
+	logging.SetLevel(level)
+	var out io.Writer = os.Stdout
+	if cmd.logToStderr {
+		out = os.Stderr
+	}
+	log.SetOutput(out)
+	logger := log.New(out, "DATA-SYNC-SERVICE: ", log.LstdFlags|log.Lshortfile)
+
+	// File .env (jika ada) hanya mengisi environment variables
 	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: Could not load .env file")
+		logging.Warnf(logger, "Warning: Could not load .env file")
 	}
-	logger := log.New(os.Stdout, "DATA-SYNC-SERVICE: ", log.LstdFlags|log.Lshortfile)
 
-	// Load Configuration
-	cfg := config.New()
-	// Pastikan username dan password tidak kosong
-	if cfg.APIUsername == "" || cfg.APIPassword == "" {
-		logger.Fatal("FATAL: API_USERNAME and API_PASSWORD environment variables must be set.")
-	}
-	// Definisikan flag untuk command line
-	// Akan membaca flag seperti: -prov="11,12,51"
-	provinsiPtr := flag.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (contoh: 11,12,51)")
-	kabupatenPtr := flag.String("kab", "", "Kode kabupaten untuk memulai proses (opsional)")
-	flag.Parse() // Baca semua flag yang didefinisikan
-	// Setup Dependencies
-	// Koneksi DB
-	db, err := sqlx.Connect("postgres", cfg.DatabaseURL)
+	// Load Configuration: nilai bawaan < file -config < env < flag
+	cfg, err := config.Load(*configPath)
 	if err != nil {
-		logger.Fatalf("FATAL: Could not connect to database: %v", err)
+		logging.Errorf(logger, "FATAL: %v", err)
+		return exitFatal
+	}
+	if *tahun != "" {
+		years, err := config.ParseYears(*tahun)
+		if err != nil {
+			logging.Errorf(logger, "Error: -tahun: %v", err)
+			return exitUsage
+		}
+		cfg.APIDataTahun = years
+	}
+	if !cmd.skipValidate {
+		if err := cfg.Validate(); err != nil {
+			logging.Errorf(logger, "FATAL: %v", err)
+			return exitUsage
+		}
 	}
-	defer db.Close()
-	// ---- KONFIGURASI POOL----
 
-	// SetConnMaxLifetime: Durasi maksimum koneksi boleh dibuka.
-	// Mengaturnya lebih rendah dari timeout firewall (misal 5 menit) akan
-	// secara otomatis mendaur ulang koneksi sebelum diputus oleh firewall.
-	db.SetConnMaxLifetime(10 * time.Minute)
+	return cmd.run(cfg, logger, global.Args()[1:])
+}
 
-	// SetMaxIdleConns: Jumlah maksimum koneksi yang boleh idle di pool.
-	db.SetMaxIdleConns(10)
+// findCommand mencari subcommand berdasarkan nama.
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
 
-	// SetMaxOpenConns: Jumlah maksimum koneksi yang boleh dibuka ke database.
-	db.SetMaxOpenConns(100)
+// withDefaultCommand menyisipkan defaultCommand sebelum argumen pertama yang
+// bukan flag global jika args tidak menyebut subcommand, sehingga pemanggilan
+// lama seperti "-prov 51 -kab 03" atau tanpa argumen tetap menjalankan sync.
+func withDefaultCommand(global *flag.FlagSet, args []string) []string {
+	i := 0
+	for i < len(args) {
+		arg := args[i]
+		if arg == "-" || !strings.HasPrefix(arg, "-") {
+			// Subcommand sudah disebut (dikenal atau tidak, diperiksa pemanggil)
+			return args
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "" || name == "h" || name == "help" {
+			return args
+		}
+		if global.Lookup(name) == nil {
+			// Flag milik subcommand: mulai dari sini argumen untuk defaultCommand
+			break
+		}
+		// Semua flag global berupa string; nilainya argumen berikutnya jika tidak memakai "="
+		i++
+		if !hasValue {
+			i++
+		}
+	}
+	if i > len(args) {
+		// Flag global terakhir tanpa nilai; biarkan flag.Parse yang melaporkan
+		return args
+	}
+	withCommand := append([]string{}, args[:i]...)
+	withCommand = append(withCommand, defaultCommand)
+	return append(withCommand, args[i:]...)
+}
 
-	// SetConnMaxIdleTime: Durasi maksimum koneksi boleh idle sebelum ditutup.
-	// Ini membantu membuang koneksi yang tidak terpakai.
-	db.SetConnMaxIdleTime(10 * time.Minute)
+// printUsage menampilkan flag global dan daftar subcommand.
+func printUsage(global *flag.FlagSet) {
+	out := global.Output()
+	fmt.Fprintf(out, "Usage: %s [flag global] [subcommand] [flag]\n\nSubcommand (bawaan: %s):\n", programName, defaultCommand)
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nGunakan '%s <subcommand> -h' untuk flag tiap subcommand.\n\nFlag global:\n", programName)
+	global.PrintDefaults()
+}
 
-	// ---------------------------------------------
-	// HTTP Client - dikonfigurasi sekali dan di-inject
-	httpClient := &http.Client{
-		Timeout: 120 * time.Minute,
+// newFlagSet membuat FlagSet untuk subcommand dengan baris usage yang seragam.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s [flag global] %s %s\n", programName, name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags mem-parse args dan mengembalikan ok=false beserta exit code
+// jika proses harus berhenti (-h menghasilkan exitOK, flag salah exitUsage).
+func parseFlags(fs *flag.FlagSet, args []string) (int, bool) {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	return exitOK, true
+}
+
+// parseProvinsi memecah flag -prov yang dipisahkan koma; kosong berarti semua provinsi.
+func parseProvinsi(value string) []string {
+	if value == "" {
+		return nil
 	}
-	// Proses input dari flag
 	var daftarProvinsi []string
-	if *provinsiPtr != "" {
-		// Pisahkan string menjadi slice berdasarkan koma
-		daftarProvinsi = strings.Split(*provinsiPtr, ",")
-		logger.Printf("Akan memproses data untuk provinsi: %v", daftarProvinsi)
-	} else {
-		logger.Println("Tidak ada kode provinsi yang ditentukan. Untuk memproses semua, biarkan flag -prov kosong.")
-	}
-	// Ambil nilai dari flag kabupaten
-	startKabupaten := *kabupatenPtr
-	if startKabupaten != "" {
-		// Ambil nilai mentah dari flag
-		kodeKabStr := strings.TrimSpace(*kabupatenPtr)
-		// Lakukan formatting yang sama seperti yang kita lakukan pada data lain
-		num, err := strconv.Atoi(kodeKabStr)
-		if err != nil {
-			logger.Fatalf("Error: Kode kabupaten '%s' bukan angka yang valid.", kodeKabStr)
+	for _, kode := range strings.Split(value, ",") {
+		if kode = strings.TrimSpace(kode); kode != "" {
+			daftarProvinsi = append(daftarProvinsi, kode)
 		}
-		// Format menjadi string 2 digit
-		startKabupaten = fmt.Sprintf("%02d", num)
-		logger.Printf("Proses akan dimulai dari kabupaten dengan kode yang diformat: %s", startKabupaten)
 	}
-	// 3. Create Concrete Implementations
-	// Berikan semua konfigurasi yang dibutuhkan oleh Fetcher
-	dataFetcher := fetcher.NewHTTPFetcher(
+	return daftarProvinsi
+}
+
+// requireCredentials memastikan kredensial API tersedia untuk subcommand yang memanggil API.
+func requireCredentials(cfg *config.Config, logger *log.Logger) bool {
+	if cfg.APIUsername == "" || cfg.APIPassword == "" {
+		logging.Errorf(logger, "FATAL: API_USERNAME and API_PASSWORD environment variables must be set.")
+		return false
+	}
+	return true
+}
+
+// newFetcher membuat Fetcher HTTP dari konfigurasi.
+func newFetcher(cfg *config.Config) fetcher.Fetcher {
+	// HTTP Client - dikonfigurasi sekali dan di-inject.
+	// Batas waktu per request diatur lewat context (API_PAGE_TIMEOUT),
+	// Timeout di sini hanya pengaman terakhir.
+	httpClient := &http.Client{
+		Timeout: 120 * time.Minute,
+	}
+	return fetcher.NewHTTPFetcher(
 		httpClient,
 		cfg.APIURL,
 		cfg.APILoginURL,
 		cfg.APIUsername,
 		cfg.APIPassword,
-		cfg.APIDataTahun,
+		fetcher.RetryPolicy{
+			MaxAttempts:     cfg.APIRetryMaxAttempts,
+			BaseDelay:       cfg.APIRetryBaseDelay,
+			MaxDelay:        cfg.APIRetryMaxDelay,
+			Jitter:          cfg.APIRetryJitter,
+			RetryableStatus: cfg.APIRetryStatusCodes,
+		},
+		cfg.APIMaxResponseBytes,
+		fetcher.NewRateLimiter(cfg.APIRateLimitRPS, cfg.APIRateLimitBurst),
+		cfg.APIPageTimeout,
 	)
-	dataStorer := storer.NewDBStorer(db)
+}
 
-	// 4. Compose The Application
-	// Inject semua dependensi ke dalam synchronizer
-	postSync := synchronizer.NewAnggaranDetailSynchronizer(dataFetcher, dataStorer, logger)
+// newStorer membuat Storer database dari konfigurasi untuk run runID.
+func newStorer(db *sqlx.DB, cfg *config.Config, runID string, logger *log.Logger) storer.Storer {
+	return storer.NewDBStorer(db, storer.Options{
+		Mode:       cfg.StoreMode,
+		BatchSize:  cfg.StoreBatchSize,
+		CommitMode: cfg.StoreCommitMode,
+		Reconcile:  cfg.StoreReconcile,
+		History:    cfg.StoreHistory,
+		RunID:      runID,
+		TxTimeout:  cfg.StoreTxTimeout,
+		Logger:     logger,
+	})
+}
 
-	// 5. Run The Application
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
-	defer cancel()
+// newWilayahSource membuat sumber daftar kabupaten/kota sesuai wilayah.source.
+func newWilayahSource(db *sqlx.DB, cfg *config.Config, logger *log.Logger) (wilayah.Source, error) {
+	switch cfg.WilayahSource {
+	case wilayah.KindCSV:
+		return wilayah.NewCSVSource(cfg.WilayahCSVPath)
+	case wilayah.KindStatic:
+		return wilayah.NewStaticSource()
+	default:
+		return wilayah.NewSQLSource(db, wilayah.SQLOptions{
+			Table:           cfg.WilayahTable,
+			ProvinsiColumn:  cfg.WilayahProvinsiColumn,
+			KabupatenColumn: cfg.WilayahKabupatenColumn,
+			Logger:          logger,
+		})
+	}
+}
+
+// newRunID membuat ID run berbasis waktu UTC ditambah sufiks acak agar
+// beberapa run yang dimulai pada detik yang sama tetap unik.
+func newRunID() string {
+	return fmt.Sprintf("%s-%04x", time.Now().UTC().Format("20060102T150405Z"), rand.Intn(1<<16))
+}
 
-	if err := postSync.Synchronize(ctx, daftarProvinsi, startKabupaten); err != nil {
-		logger.Fatalf("FATAL: Post synchronization process failed: %v", err)
+// connectDB membuka koneksi ke database dan mengatur connection pool.
+func connectDB(cfg *config.Config) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("postgres", cfg.DatabaseURL)
+	if err != nil {
+		return nil, err
 	}
+	// ---- KONFIGURASI POOL----
+
+	// SetConnMaxLifetime: Durasi maksimum koneksi boleh dibuka.
+	// Mengaturnya lebih rendah dari timeout firewall (misal 5 menit) akan
+	// secara otomatis mendaur ulang koneksi sebelum diputus oleh firewall.
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime)
+
+	// SetMaxIdleConns: Jumlah maksimum koneksi yang boleh idle di pool.
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+
+	// SetMaxOpenConns: Jumlah maksimum koneksi yang boleh dibuka ke database.
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+
+	// SetConnMaxIdleTime: Durasi maksimum koneksi boleh idle sebelum ditutup.
+	// Ini membantu membuang koneksi yang tidak terpakai.
+	db.SetConnMaxIdleTime(cfg.DBConnMaxIdleTime)
 
-	logger.Println("Application finished successfully.")
+	return db, nil
+}
+
+// buildVersion mengembalikan versi dari ldflags, atau revisi git yang
+// direkam otomatis oleh go build jika ldflags tidak diisi.
+func buildVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "dev"
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithDefaultCommand(t *testing.T) {
+	global := flag.NewFlagSet(programName, flag.ContinueOnError)
+	global.String("config", "", "")
+	global.String("log-level", "", "")
+	global.String("tahun", "", "")
+
+	tests := []struct {
+		args string
+		want string
+	}{
+		{"", "sync"},
+		{"sync -prov 51", "sync -prov 51"},
+		{"status", "status"},
+		{"unknown -x", "unknown -x"},
+		{"-prov 51 -kab 03", "sync -prov 51 -kab 03"},
+		{"-config app.toml -prov 51", "-config app.toml sync -prov 51"},
+		{"-config=app.toml -log-level warn", "-config=app.toml -log-level warn sync"},
+		{"--tahun 2024 export", "--tahun 2024 export"},
+		{"-log-level warn -", "-log-level warn -"},
+		{"-h", "-h"},
+		{"-config", "-config"},
+	}
+	for _, tt := range tests {
+		got := withDefaultCommand(global, strings.Fields(tt.args))
+		if want := strings.Fields(tt.want); !slices.Equal(got, want) {
+			t.Errorf("withDefaultCommand(%q) = %q, want %q", tt.args, got, want)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/migrations"
+)
+
+// runMigrate menjalankan subcommand "migrate up|down|status" dan
+// mengembalikan exit code proses.
+func runMigrate(cfg *config.Config, logger *log.Logger, args []string) int {
+	fs := newFlagSet("migrate", "[-steps N] up|down|status")
+	steps := fs.Int("steps", 1, "Jumlah migrasi yang dibatalkan oleh 'migrate down'")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not connect to database: %v", err)
+		return exitFatal
+	}
+	defer db.Close()
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not load migrations: %v", err)
+		return exitFatal
+	}
+
+	ctx := context.Background()
+	switch fs.Arg(0) {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			logging.Infof(logger, "Applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			logging.Errorf(logger, "ERROR: %v", err)
+			return exitFatal
+		}
+		if len(applied) == 0 {
+			logging.Infof(logger, "Schema sudah up to date.")
+		}
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, m := range reverted {
+			logging.Infof(logger, "Reverted %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			logging.Errorf(logger, "ERROR: %v", err)
+			return exitFatal
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			logging.Errorf(logger, "ERROR: %v", err)
+			return exitFatal
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%-45s %s\n", st.Version, st.Name, applied)
+		}
+	default:
+		fs.Usage()
+		return exitUsage
+	}
+	return exitOK
+}
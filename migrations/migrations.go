@@ -0,0 +1,257 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// File migrasi mengikuti pola <versi>_<nama>.up.sql dan <versi>_<nama>.down.sql.
+//
+//go:embed sql/*.sql
+var files embed.FS
+
+// advisoryLockID mencegah dua proses menjalankan migrasi bersamaan.
+const advisoryLockID = 7302025
+
+const createSchemaMigrationsQuery = `CREATE TABLE IF NOT EXISTS schema_migrations (
+        version    BIGINT      PRIMARY KEY,
+        name       TEXT        NOT NULL,
+        applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+    );`
+
+// Migration adalah satu versi skema beserta SQL up dan down-nya.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status menunjukkan apakah sebuah migrasi sudah diterapkan.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator menerapkan migrasi yang di-embed ke database dan mencatatnya
+// di tabel schema_migrations.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []Migration
+}
+
+// New membaca semua migrasi yang di-embed, diurutkan berdasarkan versi.
+func New(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Up menerapkan semua migrasi yang belum diterapkan dan mengembalikan daftarnya.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, mig.Up, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mig.Version, mig.Name); err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down membatalkan sejumlah steps migrasi terakhir yang sudah diterapkan.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if err := apply(ctx, conn, mig.Down, `DELETE FROM schema_migrations WHERE version = $1`, mig.Version); err != nil {
+				return fmt.Errorf("migration %04d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status mengembalikan semua migrasi yang dikenal beserta waktu penerapannya.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		done, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			st := Status{Migration: mig}
+			if at, ok := done[mig.Version]; ok {
+				st.AppliedAt = &at
+			}
+			statuses = append(statuses, st)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Pending mengembalikan migrasi yang di-embed tetapi belum diterapkan, urut
+// berdasarkan versi. Dipakai untuk menolak sync terhadap skema yang tertinggal.
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, st := range statuses {
+		if st.AppliedAt == nil {
+			pending = append(pending, st.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// withLock menjalankan fn di satu koneksi yang memegang advisory lock
+// setelah memastikan tabel schema_migrations ada.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "migration_connect", Err: err}
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "migration_lock", Err: err}
+	}
+	// Lepaskan lock meskipun context sudah dibatalkan
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, advisoryLockID)
+
+	if _, err := conn.ExecContext(ctx, createSchemaMigrationsQuery); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "create_schema_migrations", Err: err}
+	}
+	return fn(conn)
+}
+
+// appliedVersions membaca versi yang sudah diterapkan beserta waktunya.
+func appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int64]time.Time, error) {
+	var rows []struct {
+		Version   int64     `db:"version"`
+		AppliedAt time.Time `db:"applied_at"`
+	}
+	if err := conn.SelectContext(ctx, &rows, `SELECT version, applied_at FROM schema_migrations`); err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "read_schema_migrations", Err: err}
+	}
+	done := make(map[int64]time.Time, len(rows))
+	for _, r := range rows {
+		done[r.Version] = r.AppliedAt
+	}
+	return done, nil
+}
+
+// apply menjalankan script migrasi dan pencatatannya dalam satu transaksi.
+func apply(ctx context.Context, conn *sqlx.Conn, script, record string, args ...interface{}) error {
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "begin_transaction", Err: err}
+	}
+	defer tx.Rollback() // Aman untuk dipanggil meskipun sudah di-commit.
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "run_migration", Err: err}
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "record_migration", Err: err}
+	}
+	if err := tx.Commit(); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "commit_transaction", Err: err}
+	}
+	return nil
+}
+
+// load membaca pasangan file up/down dari fsys dan mengurutkannya.
+func load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "sql/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, name := range names {
+		base := path.Base(name)
+		var direction string
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(base, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration file %s must end in .up.sql or .down.sql", base)
+		}
+
+		stem := strings.TrimSuffix(base, "."+direction+".sql")
+		versionStr, migName, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration file %s must be named <version>_<name>", base)
+		}
+		version, err := strconv.ParseInt(versionStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s has invalid version: %w", base, err)
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: migName}
+			byVersion[version] = mig
+		}
+		if direction == "up" {
+			mig.Up = string(content)
+		} else {
+			mig.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s is missing its up or down file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
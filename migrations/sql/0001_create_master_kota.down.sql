@@ -0,0 +1,2 @@
+-- Sengaja tidak menghapus apa pun: langkah up tidak membuat tabel, dan
+-- master_kota (jika ada) adalah data referensi milik deployment.
@@ -0,0 +1,5 @@
+-- Sengaja tidak membuat apa pun. master_kota adalah data referensi milik
+-- deployment (lihat wilayah.source): bisa berupa tabel lain dengan nama kolom
+-- lain, file CSV, atau daftar bawaan, sehingga tabelnya tidak dibuat oleh
+-- migrasi. Versi ini dipertahankan agar database yang sudah menerapkannya
+-- tetap konsisten dengan daftar migrasi.
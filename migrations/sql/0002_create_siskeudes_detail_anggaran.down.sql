@@ -0,0 +1,10 @@
+-- Tabel beserta datanya tidak dihapus karena bisa saja sudah ada sebelum
+-- migrasi diperkenalkan; yang dibatalkan hanya index dan kolom audit yang
+-- ditambahkan langkah up.
+DROP INDEX IF EXISTS siskeudes_detail_anggaran_scope;
+DROP INDEX IF EXISTS siskeudes_detail_anggaran_natural_key;
+
+ALTER TABLE siskeudes_detail_anggaran
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS siskeudes_detail_anggaran (
+    tahun          TEXT NOT NULL,
+    kd_prov        TEXT NOT NULL,
+    nama_provinsi  TEXT,
+    kd_kab         TEXT NOT NULL,
+    nama_kabupaten TEXT,
+    kd_kec         TEXT NOT NULL,
+    nama_kecamatan TEXT,
+    kd_desa        TEXT NOT NULL,
+    nama_desa      TEXT,
+    kd_bid         TEXT,
+    nama_bidang    TEXT,
+    kd_sub         TEXT,
+    nama_subbidang TEXT,
+    id_keg         TEXT,
+    nama_kegiatan  TEXT,
+    kd_subrinci    TEXT NOT NULL,
+    kode_sumber    TEXT,
+    akun           TEXT NOT NULL,
+    nama_akun      TEXT,
+    kelompok       TEXT,
+    nama_kelompok  TEXT,
+    jenis          TEXT,
+    nama_jenis     TEXT,
+    obyek          TEXT NOT NULL,
+    nama_obyek     TEXT,
+    anggaran1      NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    anggaran2      NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    realisasi1     NUMERIC(20, 2) NOT NULL DEFAULT 0,
+    realisasi2     NUMERIC(20, 2) NOT NULL DEFAULT 0
+);
+
+-- Kolom audit ditambahkan terpisah agar tabel yang sudah ada sebelum
+-- migrasi diperkenalkan ikut mendapatkannya.
+ALTER TABLE siskeudes_detail_anggaran
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Dipakai oleh ON CONFLICT pada upsert.
+CREATE UNIQUE INDEX IF NOT EXISTS siskeudes_detail_anggaran_natural_key
+    ON siskeudes_detail_anggaran (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun);
+
+-- Dipakai oleh rekonsiliasi per wilayah.
+CREATE INDEX IF NOT EXISTS siskeudes_detail_anggaran_scope
+    ON siskeudes_detail_anggaran (tahun, kd_prov, kd_kab);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS sync_checkpoint (
+    tahun         INTEGER     NOT NULL,
+    kd_prov       TEXT        NOT NULL,
+    kd_kab        TEXT        NOT NULL,
+    status        TEXT        NOT NULL,
+    record_count  INTEGER     NOT NULL DEFAULT 0,
+    last_page_url TEXT,
+    error_message TEXT,
+    started_at    TIMESTAMPTZ NOT NULL,
+    updated_at    TIMESTAMPTZ NOT NULL,
+    finished_at   TIMESTAMPTZ,
+    PRIMARY KEY (tahun, kd_prov, kd_kab)
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS siskeudes_detail_anggaran_history;
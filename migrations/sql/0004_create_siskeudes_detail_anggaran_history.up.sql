@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS siskeudes_detail_anggaran_history (
+    id          BIGSERIAL PRIMARY KEY,
+    tahun       TEXT           NOT NULL,
+    kd_prov     TEXT           NOT NULL,
+    kd_kab      TEXT           NOT NULL,
+    kd_kec      TEXT           NOT NULL,
+    kd_desa     TEXT           NOT NULL,
+    id_keg      TEXT,
+    kd_subrinci TEXT           NOT NULL,
+    akun        TEXT           NOT NULL,
+    obyek       TEXT           NOT NULL,
+    anggaran1   NUMERIC(20, 2) NOT NULL,
+    anggaran2   NUMERIC(20, 2) NOT NULL,
+    realisasi1  NUMERIC(20, 2) NOT NULL,
+    realisasi2  NUMERIC(20, 2) NOT NULL,
+    valid_from  TIMESTAMPTZ    NOT NULL,
+    valid_to    TIMESTAMPTZ    NOT NULL,
+    sync_run_id TEXT
+);
+
+CREATE INDEX IF NOT EXISTS siskeudes_detail_anggaran_history_natural_key
+    ON siskeudes_detail_anggaran_history (tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, valid_from);
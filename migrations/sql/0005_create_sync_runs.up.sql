@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS sync_runs (
+    run_id      TEXT        PRIMARY KEY,
+    tahun       INTEGER     NOT NULL,
+    provinsi    TEXT[]      NOT NULL DEFAULT '{}',
+    status      TEXT        NOT NULL,
+    version     TEXT        NOT NULL,
+    started_at  TIMESTAMPTZ NOT NULL,
+    finished_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS sync_run_regions (
+    run_id         TEXT        NOT NULL REFERENCES sync_runs (run_id) ON DELETE CASCADE,
+    kd_prov        TEXT        NOT NULL,
+    kd_kab         TEXT        NOT NULL,
+    status         TEXT        NOT NULL,
+    page_count     INTEGER     NOT NULL DEFAULT 0,
+    fetched_count  INTEGER     NOT NULL DEFAULT 0,
+    inserted_count BIGINT      NOT NULL DEFAULT 0,
+    updated_count  BIGINT      NOT NULL DEFAULT 0,
+    deleted_count  BIGINT      NOT NULL DEFAULT 0,
+    duration_ms    BIGINT      NOT NULL DEFAULT 0,
+    error_message  TEXT,
+    started_at     TIMESTAMPTZ NOT NULL,
+    finished_at    TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (run_id, kd_prov, kd_kab)
+);
+
+-- Dipakai dashboard untuk mencari sinkronisasi terakhir per kabupaten.
+CREATE INDEX IF NOT EXISTS sync_run_regions_freshness
+    ON sync_run_regions (kd_prov, kd_kab, finished_at DESC);
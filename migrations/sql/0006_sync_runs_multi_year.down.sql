@@ -0,0 +1,11 @@
+-- Hanya hasil untuk tahun pertama setiap run yang dipertahankan.
+DELETE FROM sync_run_regions r
+USING sync_runs s
+WHERE s.run_id = r.run_id AND r.tahun <> s.tahun[1];
+
+ALTER TABLE sync_run_regions DROP CONSTRAINT sync_run_regions_pkey;
+ALTER TABLE sync_run_regions DROP COLUMN tahun;
+ALTER TABLE sync_run_regions ADD PRIMARY KEY (run_id, kd_prov, kd_kab);
+
+ALTER TABLE sync_runs
+    ALTER COLUMN tahun TYPE INTEGER USING tahun[1];
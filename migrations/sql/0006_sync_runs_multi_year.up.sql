@@ -0,0 +1,15 @@
+-- Satu run bisa mencakup beberapa tahun, jadi hasil per wilayah dikunci per tahun.
+ALTER TABLE sync_runs
+    ALTER COLUMN tahun TYPE INTEGER[] USING ARRAY[tahun];
+
+ALTER TABLE sync_run_regions ADD COLUMN tahun INTEGER;
+
+UPDATE sync_run_regions r
+SET tahun = s.tahun[1]
+FROM sync_runs s
+WHERE s.run_id = r.run_id;
+
+ALTER TABLE sync_run_regions ALTER COLUMN tahun SET NOT NULL;
+
+ALTER TABLE sync_run_regions DROP CONSTRAINT sync_run_regions_pkey;
+ALTER TABLE sync_run_regions ADD PRIMARY KEY (run_id, tahun, kd_prov, kd_kab);
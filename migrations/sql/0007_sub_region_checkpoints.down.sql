@@ -0,0 +1,11 @@
+-- Checkpoint dan hasil run per kecamatan/desa tidak bisa diwakili di skema lama.
+DELETE FROM sync_checkpoint WHERE kd_kec <> '' OR kd_desa <> '';
+DELETE FROM sync_run_regions WHERE kd_kec <> '' OR kd_desa <> '';
+
+ALTER TABLE sync_run_regions DROP CONSTRAINT sync_run_regions_pkey;
+ALTER TABLE sync_run_regions DROP COLUMN kd_kec, DROP COLUMN kd_desa;
+ALTER TABLE sync_run_regions ADD PRIMARY KEY (run_id, tahun, kd_prov, kd_kab);
+
+ALTER TABLE sync_checkpoint DROP CONSTRAINT sync_checkpoint_pkey;
+ALTER TABLE sync_checkpoint DROP COLUMN kd_kec, DROP COLUMN kd_desa;
+ALTER TABLE sync_checkpoint ADD PRIMARY KEY (tahun, kd_prov, kd_kab);
@@ -0,0 +1,16 @@
+-- Checkpoint dan hasil run bisa dicatat per kecamatan atau desa. String kosong
+-- berarti seluruh kabupaten (atau seluruh kecamatan), sehingga baris lama
+-- tetap bermakna sama.
+ALTER TABLE sync_checkpoint
+    ADD COLUMN kd_kec  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN kd_desa TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE sync_checkpoint DROP CONSTRAINT sync_checkpoint_pkey;
+ALTER TABLE sync_checkpoint ADD PRIMARY KEY (tahun, kd_prov, kd_kab, kd_kec, kd_desa);
+
+ALTER TABLE sync_run_regions
+    ADD COLUMN kd_kec  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN kd_desa TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE sync_run_regions DROP CONSTRAINT sync_run_regions_pkey;
+ALTER TABLE sync_run_regions ADD PRIMARY KEY (run_id, tahun, kd_prov, kd_kab, kd_kec, kd_desa);
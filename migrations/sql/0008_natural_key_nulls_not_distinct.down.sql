@@ -0,0 +1,7 @@
+-- Kembali ke unique index biasa (NULL dianggap berbeda). Index ini selalu bisa
+-- dibuat ulang karena data yang valid untuk NULLS NOT DISTINCT juga valid di
+-- sini; duplikat yang dibuang langkah up tidak dikembalikan.
+DROP INDEX IF EXISTS siskeudes_detail_anggaran_natural_key;
+
+CREATE UNIQUE INDEX siskeudes_detail_anggaran_natural_key
+    ON siskeudes_detail_anggaran (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun);
@@ -0,0 +1,22 @@
+-- Unique index natural key memperlakukan NULL sebagai berbeda, sehingga baris
+-- dengan id_keg NULL diduplikasi setiap kali disinkronkan. Dengan NULLS NOT
+-- DISTINCT (PostgreSQL 15+) id_keg NULL tetap disimpan sebagai NULL tetapi
+-- ikut bentrok di ON CONFLICT seperti nilai lain.
+
+-- Index baru tidak bisa dibuat selama duplikat masih ada. Duplikat dengan
+-- kunci natural yang sama (id_keg NULL) adalah salinan baris yang sama dari
+-- sync sebelumnya; sisakan yang paling baru diperbarui. Baris yang dihapus
+-- tidak dikembalikan oleh langkah down.
+DELETE FROM siskeudes_detail_anggaran t
+    USING siskeudes_detail_anggaran d
+    WHERE t.id_keg IS NULL AND d.id_keg IS NULL
+        AND t.kd_prov = d.kd_prov AND t.kd_kab = d.kd_kab AND t.kd_kec = d.kd_kec
+        AND t.kd_desa = d.kd_desa AND t.kd_subrinci = d.kd_subrinci
+        AND t.akun = d.akun AND t.obyek = d.obyek AND t.tahun = d.tahun
+        AND (t.updated_at, t.ctid) < (d.updated_at, d.ctid);
+
+DROP INDEX IF EXISTS siskeudes_detail_anggaran_natural_key;
+
+CREATE UNIQUE INDEX siskeudes_detail_anggaran_natural_key
+    ON siskeudes_detail_anggaran (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun)
+    NULLS NOT DISTINCT;
@@ -0,0 +1 @@
+ALTER TABLE siskeudes_detail_anggaran DROP COLUMN IF EXISTS values_changed_at;
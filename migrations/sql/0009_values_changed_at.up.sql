@@ -0,0 +1,11 @@
+-- updated_at bergeser untuk setiap perubahan kolom, termasuk perubahan nama
+-- saja. values_changed_at hanya bergeser saat anggaran/realisasi berubah dan
+-- dipakai sebagai awal berlakunya versi nilai saat ini di riwayat. Baris lama
+-- memakai updated_at sebagai perkiraan terbaik.
+ALTER TABLE siskeudes_detail_anggaran ADD COLUMN values_changed_at TIMESTAMPTZ;
+
+UPDATE siskeudes_detail_anggaran SET values_changed_at = updated_at;
+
+ALTER TABLE siskeudes_detail_anggaran
+    ALTER COLUMN values_changed_at SET DEFAULT now(),
+    ALTER COLUMN values_changed_at SET NOT NULL;
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/synchronizer"
+)
+
+// parseSelection menggabungkan flag -prov, -wilayah, -kab dan -end-kab menjadi
+// synchronizer.Selection. ok=false jika ada flag yang tidak valid.
+func parseSelection(logger *log.Logger, provinsi, wilayah, startKab, endKab string) (synchronizer.Selection, bool) {
+	selector, err := parseSelector(provinsi, wilayah)
+	if err != nil {
+		logging.Errorf(logger, "Error: %v", err)
+		return synchronizer.Selection{}, false
+	}
+	if len(selector.Include) == 0 {
+		logging.Infof(logger, "Tidak ada wilayah yang ditentukan, semua provinsi akan diproses.")
+	}
+	logging.Infof(logger, "Akan memproses wilayah: %s", selector)
+
+	selection := synchronizer.Selection{Selector: selector}
+	if selection.StartKabupaten, err = formatKabupaten(startKab); err != nil {
+		logging.Errorf(logger, "Error: -kab: %v", err)
+		return synchronizer.Selection{}, false
+	}
+	if selection.EndKabupaten, err = formatKabupaten(endKab); err != nil {
+		logging.Errorf(logger, "Error: -end-kab: %v", err)
+		return synchronizer.Selection{}, false
+	}
+	if selection.StartKabupaten != "" {
+		logging.Infof(logger, "Proses akan dimulai dari kabupaten dengan kode yang diformat: %s", selection.StartKabupaten)
+	}
+	if selection.EndKabupaten != "" {
+		logging.Infof(logger, "Proses akan berhenti setelah kabupaten dengan kode yang diformat: %s", selection.EndKabupaten)
+	}
+	return selection, true
+}
+
+// parseSelector membaca daftar pemilih wilayah yang dipisahkan koma atau spasi.
+// Setiap elemen berupa salah satu dari:
+//
+//	51          seluruh kabupaten/kota di provinsi 51 (sama dengan 51.*)
+//	51.03       satu kabupaten/kota
+//	51.03-51.10 rentang kabupaten dalam satu provinsi (boleh ditulis 51.03-10)
+//	!35.78      pengecualian; berlaku untuk semua bentuk di atas
+//	@wilayah.txt daftar dari file, satu atau lebih elemen per baris, # untuk komentar
+//
+// Beberapa spec (misal -prov dan -wilayah) digabung menjadi satu selector.
+func parseSelector(specs ...string) (synchronizer.RegionSelector, error) {
+	var selector synchronizer.RegionSelector
+	for _, spec := range specs {
+		if err := addSelectorTokens(&selector, spec, ""); err != nil {
+			return synchronizer.RegionSelector{}, err
+		}
+	}
+	return selector, nil
+}
+
+// addSelectorTokens menambahkan setiap elemen spec ke selector. source berisi
+// nama file jika spec berasal dari @file, untuk pesan error dan mencegah @ bersarang.
+func addSelectorTokens(selector *synchronizer.RegionSelector, spec, source string) error {
+	tokens := strings.FieldsFunc(spec, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	for _, token := range tokens {
+		if path, ok := strings.CutPrefix(token, "@"); ok {
+			if source != "" {
+				return fmt.Errorf("%s: @file bersarang tidak didukung: %s", source, token)
+			}
+			if err := addSelectorFile(selector, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, exclude := strings.CutPrefix(token, "!")
+		pattern, err := parseRegionPattern(raw)
+		if err != nil {
+			if source != "" {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+			return err
+		}
+		if exclude {
+			selector.Exclude = append(selector.Exclude, pattern)
+		} else {
+			selector.Include = append(selector.Include, pattern)
+		}
+	}
+	return nil
+}
+
+// addSelectorFile membaca elemen pemilih dari file; teks setelah # diabaikan.
+func addSelectorFile(selector *synchronizer.RegionSelector, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("gagal membaca daftar wilayah: %w", err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		lines = append(lines, line)
+	}
+	return addSelectorTokens(selector, strings.Join(lines, "\n"), path)
+}
+
+// parseRegionPattern mem-parse satu elemen tanpa awalan !.
+func parseRegionPattern(raw string) (synchronizer.RegionPattern, error) {
+	from, to, isRange := strings.Cut(raw, "-")
+	prov, kab, hasKab := strings.Cut(from, ".")
+	if !isDigits(prov) {
+		return synchronizer.RegionPattern{}, fmt.Errorf("kode provinsi %q tidak valid", raw)
+	}
+	pattern := synchronizer.RegionPattern{KodeProvinsi: prov}
+	if !hasKab || kab == "*" {
+		if isRange {
+			return synchronizer.RegionPattern{}, fmt.Errorf("rentang %q harus memakai kode kabupaten, misal 51.03-51.10", raw)
+		}
+		return pattern, nil
+	}
+
+	start, err := parseKabupaten(kab)
+	if err != nil {
+		return synchronizer.RegionPattern{}, fmt.Errorf("%q: %w", raw, err)
+	}
+	pattern.From, pattern.To = start, start
+	if !isRange {
+		return pattern, nil
+	}
+
+	// Batas akhir boleh ditulis lengkap (51.10) atau hanya kode kabupaten (10)
+	if endProv, endKab, ok := strings.Cut(to, "."); ok {
+		if endProv != prov {
+			return synchronizer.RegionPattern{}, fmt.Errorf("rentang %q harus berada dalam satu provinsi", raw)
+		}
+		to = endKab
+	}
+	end, err := parseKabupaten(to)
+	if err != nil {
+		return synchronizer.RegionPattern{}, fmt.Errorf("%q: %w", raw, err)
+	}
+	if end < start {
+		return synchronizer.RegionPattern{}, fmt.Errorf("rentang %q terbalik", raw)
+	}
+	pattern.To = end
+	return pattern, nil
+}
+
+// parseKabupaten mengubah kode kabupaten seperti "03" atau "3" menjadi angka.
+func parseKabupaten(raw string) (int, error) {
+	num, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil || num <= 0 {
+		return 0, fmt.Errorf("kode kabupaten '%s' bukan angka yang valid", raw)
+	}
+	return num, nil
+}
+
+// formatKabupaten menormalkan kode kabupaten dari flag menjadi string 2 digit,
+// format yang sama dengan kode di master wilayah. Kosong tetap kosong.
+func formatKabupaten(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	num, err := parseKabupaten(raw)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", num), nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
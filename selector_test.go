@@ -0,0 +1,161 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/synchronizer"
+)
+
+func TestParseRegionPattern(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want synchronizer.RegionPattern
+	}{
+		{"51", synchronizer.RegionPattern{KodeProvinsi: "51"}},
+		{"51.*", synchronizer.RegionPattern{KodeProvinsi: "51"}},
+		{"51.03", synchronizer.RegionPattern{KodeProvinsi: "51", From: 3, To: 3}},
+		{"51.3", synchronizer.RegionPattern{KodeProvinsi: "51", From: 3, To: 3}},
+		{"51.03-51.10", synchronizer.RegionPattern{KodeProvinsi: "51", From: 3, To: 10}},
+		{"51.03-10", synchronizer.RegionPattern{KodeProvinsi: "51", From: 3, To: 10}},
+		{"51.05-05", synchronizer.RegionPattern{KodeProvinsi: "51", From: 5, To: 5}},
+	}
+	for _, tt := range tests {
+		got, err := parseRegionPattern(tt.raw)
+		if err != nil {
+			t.Errorf("parseRegionPattern(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseRegionPattern(%q) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParseRegionPatternErrors(t *testing.T) {
+	tests := []struct {
+		raw     string
+		wantErr string
+	}{
+		{"", "kode provinsi"},
+		{"5a", "kode provinsi"},
+		{".03", "kode provinsi"},
+		{"51.xx", "bukan angka yang valid"},
+		{"51.00", "bukan angka yang valid"},
+		{"51.03-", "bukan angka yang valid"},
+		{"51.03-ab", "bukan angka yang valid"},
+		{"51-52", "harus memakai kode kabupaten"},
+		{"51.*-10", "harus memakai kode kabupaten"},
+		{"51.03-52.10", "harus berada dalam satu provinsi"},
+		{"51.10-51.03", "terbalik"},
+	}
+	for _, tt := range tests {
+		_, err := parseRegionPattern(tt.raw)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parseRegionPattern(%q) error = %v, want it to contain %q", tt.raw, err, tt.wantErr)
+		}
+	}
+}
+
+// masterWilayah adalah daftar master kecil untuk menguji hasil pilihan.
+var masterWilayah = []domain.Wilayah{
+	{KodeProvinsi: "51", KodeKabupaten: "03"},
+	{KodeProvinsi: "51", KodeKabupaten: "04"},
+	{KodeProvinsi: "51", KodeKabupaten: "71"},
+	{KodeProvinsi: "52", KodeKabupaten: "01"},
+	{KodeProvinsi: "52", KodeKabupaten: "03"},
+	{KodeProvinsi: "53", KodeKabupaten: "01"},
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name     string
+		provinsi string
+		wilayah  string
+		want     string // Hasil Filter atas masterWilayah, "prov.kab" dipisahkan spasi
+	}{
+		{"nothing selects all", "", "", "51.03 51.04 51.71 52.01 52.03 53.01"},
+		{"provinsi only", "51", "", "51.03 51.04 51.71"},
+		{"union with -prov", "51", "52.03", "51.03 51.04 51.71 52.03"},
+		{"separators", "", "51.03, 52.01\t53", "51.03 52.01 53.01"},
+		{"range", "", "51.03-04", "51.03 51.04"},
+		{"exclude from -prov", "51,52", "!51.71 !52.01-52.02", "51.03 51.04 52.03"},
+		{"exclude without include", "", "!51", "52.01 52.03 53.01"},
+		{"duplicates select once", "51", "51 51.03 51.03-51.04", "51.03 51.04 51.71"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := parseSelector(tt.provinsi, tt.wilayah)
+			if err != nil {
+				t.Fatalf("parseSelector: %v", err)
+			}
+			var got []string
+			for _, w := range selector.Filter(masterWilayah) {
+				got = append(got, w.KodeProvinsi+"."+w.KodeKabupaten)
+			}
+			if want := strings.Fields(tt.want); !slices.Equal(got, want) {
+				t.Errorf("selected %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestParseSelectorProvinsiDeduplicated(t *testing.T) {
+	selector, err := parseSelector("51", "51.03,52.01,51.71")
+	if err != nil {
+		t.Fatalf("parseSelector: %v", err)
+	}
+	if got, want := selector.Provinsi(), []string{"51", "52"}; !slices.Equal(got, want) {
+		t.Errorf("Provinsi() = %q, want %q", got, want)
+	}
+}
+
+func TestParseSelectorFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wilayah.txt")
+	if err := os.WriteFile(path, []byte("# Bali\n51.03, 51.04 # dua kabupaten\n\n!51.04\n52.01\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	selector, err := parseSelector("53", "@"+path)
+	if err != nil {
+		t.Fatalf("parseSelector: %v", err)
+	}
+	if got, want := selector.String(), "53,51.03,51.04,52.01,!51.04"; got != want {
+		t.Errorf("got selector %s, want %s", got, want)
+	}
+}
+
+func TestParseSelectorErrors(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "nested.txt")
+	invalid := filepath.Join(dir, "invalid.txt")
+	os.WriteFile(nested, []byte("51\n@other.txt\n"), 0o644)
+	os.WriteFile(invalid, []byte("51.03\n51.xx\n"), 0o644)
+
+	tests := []struct {
+		name     string
+		provinsi string
+		wilayah  string
+		wantErr  string
+	}{
+		{"invalid -prov", "5x", "", `kode provinsi "5x" tidak valid`},
+		{"invalid -wilayah", "51", "51.03,52.10-52.01", "terbalik"},
+		{"invalid exclusion", "", "!51.00", "bukan angka yang valid"},
+		{"missing file", "", "@" + filepath.Join(dir, "missing.txt"), "gagal membaca daftar wilayah"},
+		{"nested file", "", "@" + nested, nested + ": @file bersarang tidak didukung"},
+		{"invalid line in file", "", "@" + invalid, invalid + `: "51.xx"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSelector(tt.provinsi, tt.wilayah)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
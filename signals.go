@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+)
+
+// handleSignals mengubah SIGINT/SIGTERM pertama menjadi interupsi yang halus:
+// channel yang dikembalikan ditutup sehingga synchronizer berhenti membagikan
+// wilayah baru dan menyelesaikan wilayah yang sedang berjalan. Sinyal kedua
+// menghentikan proses seketika dengan exitForced; transaksi yang terbuka akan
+// di-rollback oleh database saat koneksi terputus.
+// release wajib dipanggil setelah sinkronisasi selesai.
+func handleSignals(logger *log.Logger) (interrupt <-chan struct{}, release func()) {
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	interrupted := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-done:
+			return
+		case <-sigCtx.Done():
+			// sigCtx juga dibatalkan oleh release; done sudah ditutup lebih dulu
+			select {
+			case <-done:
+				return
+			default:
+			}
+		}
+		// Daftarkan channel untuk sinyal kedua sebelum interupsi diteruskan
+		force := make(chan os.Signal, 1)
+		signal.Notify(force, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(force)
+
+		logging.Warnf(logger, "Sinyal diterima, menyelesaikan wilayah yang sedang berjalan. Kirim sinyal sekali lagi untuk keluar paksa.")
+		close(interrupted)
+
+		select {
+		case <-done:
+		case sig := <-force:
+			logging.Warnf(logger, "Sinyal %v diterima lagi, keluar paksa.", sig)
+			os.Exit(exitForced)
+		}
+	}()
+
+	return interrupted, func() {
+		close(done)
+		stop()
+	}
+}
+
+// resumeHint membentuk perintah untuk melanjutkan run yang diinterupsi:
+// argumen yang sama ditambah -resume agar wilayah yang sudah selesai dilewati.
+func resumeHint(args []string) string {
+	hint := append([]string{}, args...)
+	hasResume := false
+	for _, arg := range hint[1:] {
+		name := strings.TrimLeft(arg, "-")
+		if name == "resume" || name == "resume=true" {
+			hasResume = true
+		}
+	}
+	if !hasResume {
+		hint = append(hint, "-resume")
+	}
+	quoted := make([]string, len(hint))
+	for i, arg := range hint {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote membungkus arg dengan kutip tunggal jika mengandung karakter
+// yang ditafsirkan shell, agar hint bisa disalin dan dijalankan apa adanya.
+// Kutip tunggal di dalam arg ditutup, di-escape lalu dibuka lagi.
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, isShellUnsafe) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// isShellUnsafe bernilai true untuk karakter yang perlu dikutip di shell POSIX.
+func isShellUnsafe(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	default:
+		return !strings.ContainsRune("-_./=:,@%+", r)
+	}
+}
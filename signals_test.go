@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestResumeHint(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"synctodb"}, "synctodb -resume"},
+		{[]string{"./synctodb", "sync", "-prov", "51"}, "./synctodb sync -prov 51 -resume"},
+		{[]string{"synctodb", "sync", "-resume", "-prov", "51"}, "synctodb sync -resume -prov 51"},
+		{[]string{"synctodb", "sync", "--resume=true"}, "synctodb sync --resume=true"},
+		// -resume=false dibatalkan oleh -resume terakhir
+		{[]string{"synctodb", "sync", "-resume=false"}, "synctodb sync -resume=false -resume"},
+		{[]string{"/opt/sync tool/synctodb", "-wilayah", "51.03 52", "-config", "it's.toml"},
+			`'/opt/sync tool/synctodb' -wilayah '51.03 52' -config 'it'\''s.toml' -resume`},
+		// Nama program yang kebetulan "resume" bukan flag
+		{[]string{"resume", "sync"}, "resume sync -resume"},
+	}
+	for _, tt := range tests {
+		if got := resumeHint(tt.args); got != tt.want {
+			t.Errorf("resumeHint(%q) = %s, want %s", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"sync", "sync"},
+		{"-prov=51,52", "-prov=51,52"},
+		{"./bin/synctodb", "./bin/synctodb"},
+		{"user@host:5432/db%20", "user@host:5432/db%20"},
+		{"", "''"},
+		{"51 52", "'51 52'"},
+		{"!51.71", "'!51.71'"},
+		{"$HOME", "'$HOME'"},
+		{"a;rm -rf", "'a;rm -rf'"},
+		{"it's", `'it'\''s'`},
+		{"*", "'*'"},
+		{"tab\there", "'tab\there'"},
+		{"kabupaten é", "'kabupaten é'"},
+	}
+	for _, tt := range tests {
+		if got := shellQuote(tt.arg); got != tt.want {
+			t.Errorf("shellQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
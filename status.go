@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// runStatus menjalankan subcommand "status": menampilkan run terakhir dan
+// checkpoint per wilayah untuk tahun yang dipilih.
+func runStatus(cfg *config.Config, logger *log.Logger, args []string) int {
+	fs := newFlagSet("status", "[flag]")
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (kosong berarti semua)")
+	runsPtr := fs.Int("runs", 10, "Jumlah run terakhir yang ditampilkan")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not connect to database: %v", err)
+		return exitFatal
+	}
+	defer db.Close()
+	dataStorer := newStorer(db, cfg, "", logger)
+
+	ctx := context.Background()
+	runs, err := dataStorer.GetRecentRuns(ctx, *runsPtr)
+	if err != nil {
+		logging.Errorf(logger, "ERROR: %v", err)
+		return exitFatal
+	}
+	var checkpoints []storer.Checkpoint
+	for _, tahun := range cfg.APIDataTahun {
+		cps, err := dataStorer.GetCheckpoints(ctx, tahun, parseProvinsi(*provinsiPtr))
+		if err != nil {
+			logging.Errorf(logger, "ERROR: %v", err)
+			return exitFatal
+		}
+		checkpoints = append(checkpoints, cps...)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Run terakhir:\n")
+	fmt.Fprintf(w, "RUN ID\tTAHUN\tSTATUS\tMULAI\tSELESAI\tVERSI\n")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.RunID, formatYears(r.Tahun), r.Status, formatTime(&r.StartedAt), formatTime(r.FinishedAt), r.Version)
+	}
+
+	fmt.Fprintf(w, "\nCheckpoint tahun %v:\n", cfg.APIDataTahun)
+	fmt.Fprintf(w, "TAHUN\tPROV\tKAB\tKEC\tDESA\tSTATUS\tDATA\tDIPERBARUI\tERROR\n")
+	counts := make(map[string]int)
+	for _, cp := range checkpoints {
+		counts[cp.Status]++
+		errMsg := ""
+		if cp.ErrorMessage != nil {
+			errMsg = *cp.ErrorMessage
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", cp.Tahun, cp.KodeProvinsi, cp.KodeKabupaten, orDash(cp.KodeKecamatan), orDash(cp.KodeDesa), cp.Status, cp.RecordCount, formatTime(&cp.UpdatedAt), errMsg)
+	}
+	w.Flush()
+
+	fmt.Printf("\n%d unit wilayah: %d selesai, %d gagal, %d berjalan.\n", len(checkpoints),
+		counts[storer.CheckpointCompleted], counts[storer.CheckpointFailed], counts[storer.CheckpointRunning])
+	return exitOK
+}
+
+// formatYears memformat daftar tahun sebuah run, misal "2023,2024".
+func formatYears(years []int64) string {
+	parts := make([]string, len(years))
+	for i, y := range years {
+		parts[i] = strconv.FormatInt(y, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// orDash menampilkan kode kosong (checkpoint tingkat kabupaten/kecamatan) sebagai "-".
+func orDash(kode string) string {
+	if kode == "" {
+		return "-"
+	}
+	return kode
+}
+
+// formatTime memformat waktu untuk tabel; nil ditampilkan sebagai "-".
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,76 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// upsertBatch menulis details dengan satu INSERT multi-baris. Pemanggil harus
+// memastikan len(details) <= maxBatchSize.
+func upsertBatch(ctx context.Context, tx *sqlx.Tx, details []domain.AnggaranDetail) (WriteStats, error) {
+	unique := dedupeByKey(details)
+	if len(unique) == 0 {
+		return WriteStats{}, nil
+	}
+
+	query, args := buildBatchUpsert(unique)
+	// Duplikat yang dibuang ikut dihitung sebagai unchanged agar total tetap len(details)
+	return execCountedUpsert(ctx, tx, "upsert_batch", query, len(details), args...)
+}
+
+// buildBatchUpsert menyusun INSERT ... VALUES ($1..$29), ($30..$58), ... ON CONFLICT,
+// tanpa titik koma agar bisa dibungkus execCountedUpsert.
+func buildBatchUpsert(details []domain.AnggaranDetail) (string, []interface{}) {
+	cols := len(anggaranDetailColumns)
+	args := make([]interface{}, 0, len(details)*cols)
+
+	var b strings.Builder
+	b.WriteString("INSERT INTO siskeudes_detail_anggaran AS t (")
+	b.WriteString(strings.Join(anggaranDetailColumns, ", "))
+	b.WriteString(") VALUES ")
+	for i, detail := range details {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := 0; c < cols; c++ {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%d", i*cols+c+1)
+		}
+		b.WriteByte(')')
+		args = append(args, anggaranDetailCopyValues(detail)...)
+	}
+	b.WriteString("\n        ")
+	b.WriteString(anggaranDetailConflictClause)
+
+	return b.String(), args
+}
+
+// dedupeByKey membuang baris dengan kunci konflik yang sama (baris terakhir menang),
+// karena ON CONFLICT DO UPDATE tidak boleh menyentuh baris yang sama dua kali
+// dalam satu statement. id_keg NULL juga bentrok karena unique index natural
+// key memakai NULLS NOT DISTINCT (migrasi 0008).
+func dedupeByKey(details []domain.AnggaranDetail) []domain.AnggaranDetail {
+	lastIndex := make(map[historyKey]int, len(details))
+	for i, d := range details {
+		lastIndex[detailKey(d)] = i
+	}
+	if len(lastIndex) == len(details) {
+		return details
+	}
+
+	unique := make([]domain.AnggaranDetail, 0, len(lastIndex))
+	for i, d := range details {
+		if lastIndex[detailKey(d)] == i {
+			unique = append(unique, d)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,93 @@
+package storer
+
+import (
+	"context"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Status yang mungkin untuk sebuah checkpoint wilayah.
+const (
+	CheckpointRunning   = "running"
+	CheckpointCompleted = "completed"
+	CheckpointFailed    = "failed"
+)
+
+// Checkpoint mencatat progres sinkronisasi untuk satu (tahun, kd_prov, kd_kab,
+// kd_kec, kd_desa). Kode memakai format API (kd_kab = "03"); KodeKecamatan dan
+// KodeDesa kosong untuk checkpoint tingkat kabupaten atau kecamatan.
+type Checkpoint struct {
+	Tahun         int        `db:"tahun"`
+	KodeProvinsi  string     `db:"kd_prov"`
+	KodeKabupaten string     `db:"kd_kab"`
+	KodeKecamatan string     `db:"kd_kec"`
+	KodeDesa      string     `db:"kd_desa"`
+	Status        string     `db:"status"`
+	RecordCount   int        `db:"record_count"`
+	LastPageURL   *string    `db:"last_page_url"`
+	ErrorMessage  *string    `db:"error_message"`
+	StartedAt     time.Time  `db:"started_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	FinishedAt    *time.Time `db:"finished_at"`
+}
+
+// CheckpointStore mendefinisikan kontrak untuk menyimpan progres per wilayah
+// sehingga proses yang terhenti bisa dilanjutkan.
+type CheckpointStore interface {
+	GetCheckpoints(ctx context.Context, tahun int, kodeProvinsi []string) ([]Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, cp Checkpoint) error
+}
+
+const (
+	// Setiap wilayah hanya punya satu baris; baris ditimpa oleh run terbaru.
+	upsertCheckpointQuery = `INSERT INTO sync_checkpoint (
+            tahun, kd_prov, kd_kab, kd_kec, kd_desa, status, record_count, last_page_url,
+            error_message, started_at, updated_at, finished_at
+        ) VALUES (
+            :tahun, :kd_prov, :kd_kab, :kd_kec, :kd_desa, :status, :record_count, :last_page_url,
+            :error_message, :started_at, :updated_at, :finished_at
+        )
+        ON CONFLICT (tahun, kd_prov, kd_kab, kd_kec, kd_desa) DO UPDATE SET
+            status = EXCLUDED.status,
+            record_count = EXCLUDED.record_count,
+            last_page_url = EXCLUDED.last_page_url,
+            error_message = EXCLUDED.error_message,
+            started_at = EXCLUDED.started_at,
+            updated_at = EXCLUDED.updated_at,
+            finished_at = EXCLUDED.finished_at;`
+)
+
+// GetCheckpoints mengambil checkpoint untuk satu tahun, opsional difilter per provinsi.
+func (s *dbStorer) GetCheckpoints(ctx context.Context, tahun int, kodeProvinsi []string) ([]Checkpoint, error) {
+	query := `SELECT tahun, kd_prov, kd_kab, kd_kec, kd_desa, status, record_count, last_page_url,
+            error_message, started_at, updated_at, finished_at
+        FROM sync_checkpoint WHERE tahun = ?`
+	args := []interface{}{tahun}
+
+	if len(kodeProvinsi) > 0 {
+		query += ` AND kd_prov IN (?)`
+		args = append(args, kodeProvinsi)
+	}
+	query += ` ORDER BY kd_prov, kd_kab, kd_kec, kd_desa`
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_checkpoints", Err: err}
+	}
+
+	var checkpoints []Checkpoint
+	if err := s.db.SelectContext(ctx, &checkpoints, s.db.Rebind(query), args...); err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_checkpoints", Err: err}
+	}
+	return checkpoints, nil
+}
+
+func (s *dbStorer) SaveCheckpoint(ctx context.Context, cp Checkpoint) error {
+	if _, err := s.db.NamedExecContext(ctx, upsertCheckpointQuery, cp); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "save_checkpoint", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// errStagingUnavailable menandakan tabel staging tidak bisa dibuat sehingga
+// penulisan jatuh kembali ke multi-row upsert (StoreModeBatch).
+var errStagingUnavailable = errors.New("staging table unavailable")
+
+// anggaranDetailColumns adalah urutan kolom untuk COPY; harus sama dengan
+// urutan nilai di anggaranDetailCopyValues.
+var anggaranDetailColumns = []string{
+	"tahun", "kd_prov", "nama_provinsi", "kd_kab", "nama_kabupaten",
+	"kd_kec", "nama_kecamatan", "kd_desa", "nama_desa", "kd_bid",
+	"nama_bidang", "kd_sub", "nama_subbidang", "id_keg", "nama_kegiatan",
+	"kd_subrinci", "kode_sumber", "akun", "nama_akun", "kelompok", "nama_kelompok",
+	"jenis", "nama_jenis", "obyek", "nama_obyek", "anggaran1", "anggaran2",
+	"realisasi1", "realisasi2",
+}
+
+const stagingTable = "siskeudes_detail_anggaran_staging"
+
+var (
+	// Tabel staging hanya berisi kolom yang di-COPY (tanpa constraint NOT NULL
+	// tambahan) dan otomatis dihapus saat transaksi selesai.
+	createStagingTableQuery = fmt.Sprintf(`CREATE TEMP TABLE IF NOT EXISTS %s ON COMMIT DROP AS
+        SELECT %s FROM siskeudes_detail_anggaran WITH NO DATA;`,
+		stagingTable, strings.Join(anggaranDetailColumns, ", "))
+
+	// DISTINCT ON membuang duplikat kunci di dalam satu batch (baris terakhir menang),
+	// karena ON CONFLICT DO UPDATE tidak boleh menyentuh baris yang sama dua kali.
+	mergeStagingQuery = fmt.Sprintf(`INSERT INTO siskeudes_detail_anggaran AS t (%[1]s)
+        SELECT DISTINCT ON (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun) %[1]s
+        FROM %[2]s
+        ORDER BY kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun, ctid DESC
+        %[3]s`,
+		strings.Join(anggaranDetailColumns, ", "), stagingTable, anggaranDetailConflictClause)
+
+	truncateStagingQuery = "TRUNCATE " + stagingTable
+)
+
+// copyDetails memuat data ke tabel staging memakai COPY lalu menggabungkannya
+// ke siskeudes_detail_anggaran dengan satu INSERT ... SELECT ... ON CONFLICT.
+// Tabel staging harus sudah dibuat di tx (lihat dbStorer.begin) dan dikosongkan
+// kembali setelah merge sehingga bisa dipakai ulang oleh chunk berikutnya.
+func copyDetails(ctx context.Context, tx *sqlx.Tx, details []domain.AnggaranDetail) (WriteStats, error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(stagingTable, anggaranDetailColumns...))
+	if err != nil {
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "prepare_copy", Err: err}
+	}
+	for _, detail := range details {
+		if _, err := stmt.ExecContext(ctx, anggaranDetailCopyValues(detail)...); err != nil {
+			stmt.Close()
+			return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "copy_row", Err: err}
+		}
+	}
+	// Exec tanpa argumen mengirim sisa buffer COPY ke server
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "copy_flush", Err: err}
+	}
+	if err := stmt.Close(); err != nil {
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "copy_close", Err: err}
+	}
+
+	stats, err := execCountedUpsert(ctx, tx, "merge_staging", mergeStagingQuery, len(details))
+	if err != nil {
+		return WriteStats{}, err
+	}
+	if _, err := tx.ExecContext(ctx, truncateStagingQuery); err != nil {
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "truncate_staging", Err: err}
+	}
+	return stats, nil
+}
+
+// anggaranDetailCopyValues mengurutkan field sesuai anggaranDetailColumns.
+func anggaranDetailCopyValues(d domain.AnggaranDetail) []interface{} {
+	return []interface{}{
+		d.Tahun, d.KodeProvinsi, d.NamaProvinsi, d.KodeKabupaten, d.NamaKabupaten,
+		d.KodeKecamatan, d.NamaKecamatan, d.KodeDesa, d.NamaDesa, d.KodeBidang,
+		d.NamaBidang, d.KodeSubBidang, d.NamaSubBidang, d.IDKegiatan, d.NamaKegiatan,
+		d.KodeSubRinci, d.KodeSumber, d.Akun, d.NamaAkun, d.Kelompok, d.NamaKelompok,
+		d.Jenis, d.NamaJenis, d.Obyek, d.NamaObyek, d.Anggaran1, d.Anggaran2,
+		d.Realisasi1, d.Realisasi2,
+	}
+}
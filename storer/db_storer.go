@@ -2,8 +2,9 @@ package storer
 
 import (
 	"context"
-	"fmt"
-	"strconv"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
 	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
@@ -11,81 +12,154 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-type Wilayah struct {
-	KodeProvinsi  string `db:"provinsi_id"`
-	KodeKabupaten string `db:"kota_id"`
-}
-
 // Storer mendefinisikan kontrak untuk menyimpan data post.
 type Storer interface {
-	StoreAnggaranDetails(ctx context.Context, details []domain.AnggaranDetail) error
-	GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error)
+	StoreAnggaranDetails(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error)
+	BeginRegion(ctx context.Context, scope RegionScope) (RegionWriter, error)
+	// CheckSchema memastikan semua migrasi yang di-embed sudah diterapkan.
+	CheckSchema(ctx context.Context) error
+	CheckpointStore
+	SubregionStore
+	HistoryStore
+	RunStore
+	ReportStore
 }
 
-// Implementasi fungsi untuk memfilter berdasarkan kd_prov
-func (s *dbStorer) GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]Wilayah, error) {
-	var wilayah []Wilayah
-
-	// Query dasar
-	baseQuery := `SELECT provinsi_id, kota_id FROM master_kota`
-
-	var args []interface{}
-
-	// Jika daftar provinsi diberikan, tambahkan klausa WHERE IN
-	if len(kodeProvinsi) > 0 {
-		baseQuery += ` WHERE provinsi_id IN (?)`
-		args = append(args, kodeProvinsi)
-	}
-
-	baseQuery += ` ORDER BY provinsi_id, kota_id`
-
-	// sqlx.In secara aman akan mengubah query (?) menjadi ($1, $2, ...)
-	// dan menyesuaikan argumennya. Ini cara aman untuk klausa IN.
-	query, args, err := sqlx.In(baseQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("gagal membuat query IN: %w", err)
-	}
-
-	// Rebind query agar sesuai dengan placeholder PostgreSQL ($1, $2)
-	query = s.db.Rebind(query)
-
-	err = s.db.SelectContext(ctx, &wilayah, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("gagal mengambil data wilayah yang difilter: %w", err)
-	}
+// Mode penyimpanan yang didukung oleh dbStorer.
+const (
+	// StoreModeRow menjalankan satu upsert per baris (paling lambat, paling kompatibel).
+	StoreModeRow = "row"
+	// StoreModeCopy memakai COPY ke tabel staging sementara lalu satu INSERT ... SELECT.
+	StoreModeCopy = "copy"
+	// StoreModeBatch memakai INSERT multi-baris per chunk, tanpa tabel sementara.
+	StoreModeBatch = "batch"
+)
 
-	// Lakukan loop untuk memformat kode kabupaten setelah data didapat
-	for i := range wilayah {
-		// Ambil kode kabupaten mentah
-		kodeKabStr := wilayah[i].KodeKabupaten
+// Granularitas commit yang didukung oleh dbStorer.
+const (
+	// CommitPerChunk meng-commit setiap chunk BatchSize baris secara terpisah.
+	CommitPerChunk = "chunk"
+	// CommitPerRegion meng-commit seluruh data satu wilayah dalam satu transaksi.
+	CommitPerRegion = "region"
+)
 
-		// Ubah string menjadi integer
-		num, err := strconv.Atoi(kodeKabStr)
-		if err != nil {
-			// Jika gagal (misal format tidak standar), biarkan apa adanya dan beri peringatan
-			fmt.Printf("Peringatan: Format kd_kab '%s' tidak valid, tidak diformat.\n", kodeKabStr)
-			continue
-		}
+// Mode rekonsiliasi baris yang sudah tidak ada di API.
+const (
+	// ReconcileOff tidak pernah menghapus baris.
+	ReconcileOff = "off"
+	// ReconcileDelete menghapus baris yang tidak ada lagi di hasil fetch terbaru.
+	ReconcileDelete = "delete"
+	// ReconcileSoft mengisi kolom deleted_at alih-alih menghapus baris.
+	ReconcileSoft = "soft"
+)
 
-		// Format integer menjadi string 2 digit dengan awalan nol
-		wilayah[i].KodeKabupaten = fmt.Sprintf("%02d", num)
-	}
+// maxBatchSize menjaga agar satu INSERT multi-baris tidak melewati batas
+// 65535 parameter PostgreSQL (29 kolom per baris).
+var maxBatchSize = 65535 / len(anggaranDetailColumns)
 
-	return wilayah, nil
+// Options berisi pengaturan opsional untuk dbStorer.
+type Options struct {
+	// Mode adalah salah satu StoreMode*; kosong berarti StoreModeRow
+	Mode string
+	// BatchSize adalah jumlah baris per chunk; dibatasi maxBatchSize. Dengan
+	// CommitPerRegion mode copy tidak dipecah per BatchSize (lihat writeChunkSize)
+	BatchSize int
+	// CommitMode adalah salah satu CommitPer*; kosong berarti CommitPerChunk
+	CommitMode string
+	// Reconcile adalah salah satu Reconcile*; kosong berarti ReconcileOff.
+	// Selain off, CommitMode dipaksa menjadi CommitPerRegion karena penghapusan
+	// harus berada dalam transaksi yang sama dengan upsert.
+	Reconcile string
+	// History menyalin versi lama ke siskeudes_detail_anggaran_history setiap
+	// kali nilai anggaran/realisasi berubah.
+	History bool
+	// RunID adalah ID run sinkronisasi yang dicatat di tabel riwayat
+	RunID string
+	// TxTimeout membatasi umur setiap transaksi, dari begin sampai commit;
+	// <= 0 berarti tanpa batas. Dengan CommitPerRegion satu transaksi
+	// mencakup seluruh wilayah.
+	TxTimeout time.Duration
+	// Logger menerima peringatan storer, misalnya mode copy yang jatuh ke
+	// batch; nil berarti logger standar
+	Logger *log.Logger
 }
 
 type dbStorer struct {
-	db *sqlx.DB
+	db   *sqlx.DB
+	opts Options
+	// stagingWarning memastikan peringatan fallback mode copy hanya ditulis
+	// sekali per proses, bukan sekali per transaksi
+	stagingWarning sync.Once
 }
 
-func NewDBStorer(db *sqlx.DB) Storer {
-	return &dbStorer{db: db}
+func NewDBStorer(db *sqlx.DB, opts Options) Storer {
+	if opts.Mode == "" {
+		opts.Mode = StoreModeRow
+	}
+	if opts.BatchSize <= 0 || opts.BatchSize > maxBatchSize {
+		opts.BatchSize = maxBatchSize
+	}
+	if opts.CommitMode == "" {
+		opts.CommitMode = CommitPerChunk
+	}
+	if opts.Reconcile == "" {
+		opts.Reconcile = ReconcileOff
+	}
+	if opts.Reconcile != ReconcileOff {
+		opts.CommitMode = CommitPerRegion
+	}
+	return &dbStorer{db: db, opts: opts}
 }
 
 const (
+	// anggaranDetailConflictClause dipakai bersama oleh upsert per baris, batch
+	// dan upsert dari tabel staging agar aturan update-nya selalu sama. Semua
+	// kolom non-kunci diperbarui, tetapi baris dilewati sama sekali jika tidak
+	// ada yang berubah sehingga updated_at hanya bergeser untuk perubahan nyata.
+	// values_changed_at hanya bergeser jika nilai anggaran/realisasi berubah.
+	// Target INSERT harus diberi alias "t".
+	anggaranDetailConflictClause = `ON CONFLICT (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun) DO UPDATE SET
+            nama_provinsi = EXCLUDED.nama_provinsi,
+            nama_kabupaten = EXCLUDED.nama_kabupaten,
+            nama_kecamatan = EXCLUDED.nama_kecamatan,
+            nama_desa = EXCLUDED.nama_desa,
+            kd_bid = EXCLUDED.kd_bid,
+            nama_bidang = EXCLUDED.nama_bidang,
+            kd_sub = EXCLUDED.kd_sub,
+            nama_subbidang = EXCLUDED.nama_subbidang,
+            nama_kegiatan = EXCLUDED.nama_kegiatan,
+            kode_sumber = EXCLUDED.kode_sumber,
+            nama_akun = EXCLUDED.nama_akun,
+            kelompok = EXCLUDED.kelompok,
+            nama_kelompok = EXCLUDED.nama_kelompok,
+            jenis = EXCLUDED.jenis,
+            nama_jenis = EXCLUDED.nama_jenis,
+            nama_obyek = EXCLUDED.nama_obyek,
+            anggaran1 = EXCLUDED.anggaran1,
+            anggaran2 = EXCLUDED.anggaran2,
+            realisasi1 = EXCLUDED.realisasi1,
+            realisasi2 = EXCLUDED.realisasi2,
+            updated_at = now(),
+            values_changed_at = CASE
+                WHEN (t.anggaran1, t.anggaran2, t.realisasi1, t.realisasi2)
+                    IS DISTINCT FROM (EXCLUDED.anggaran1, EXCLUDED.anggaran2, EXCLUDED.realisasi1, EXCLUDED.realisasi2)
+                THEN now() ELSE t.values_changed_at END
+        WHERE (
+            t.nama_provinsi, t.nama_kabupaten, t.nama_kecamatan, t.nama_desa, t.kd_bid,
+            t.nama_bidang, t.kd_sub, t.nama_subbidang, t.nama_kegiatan, t.kode_sumber,
+            t.nama_akun, t.kelompok, t.nama_kelompok, t.jenis, t.nama_jenis,
+            t.nama_obyek, t.anggaran1, t.anggaran2, t.realisasi1, t.realisasi2
+        ) IS DISTINCT FROM (
+            EXCLUDED.nama_provinsi, EXCLUDED.nama_kabupaten, EXCLUDED.nama_kecamatan, EXCLUDED.nama_desa, EXCLUDED.kd_bid,
+            EXCLUDED.nama_bidang, EXCLUDED.kd_sub, EXCLUDED.nama_subbidang, EXCLUDED.nama_kegiatan, EXCLUDED.kode_sumber,
+            EXCLUDED.nama_akun, EXCLUDED.kelompok, EXCLUDED.nama_kelompok, EXCLUDED.jenis, EXCLUDED.nama_jenis,
+            EXCLUDED.nama_obyek, EXCLUDED.anggaran1, EXCLUDED.anggaran2, EXCLUDED.realisasi1, EXCLUDED.realisasi2
+        )
+        RETURNING (xmax = 0) AS inserted`
+
 	// Query disimpan sebagai konstanta untuk menghindari 'magic strings'
 	// dan memudahkan pengelolaan.
-	upsertAnggaranDetailQuery = `INSERT INTO siskeudes_detail_anggaran (
+	upsertAnggaranDetailQuery = `INSERT INTO siskeudes_detail_anggaran AS t (
             tahun, kd_prov, nama_provinsi, kd_kab, nama_kabupaten,
             kd_kec, nama_kecamatan, kd_desa, nama_desa, kd_bid,
             nama_bidang, kd_sub, nama_subbidang, id_keg, nama_kegiatan,
@@ -100,29 +174,108 @@ const (
             :jenis, :nama_jenis, :obyek, :nama_obyek, :anggaran1, :anggaran2,
             :realisasi1, :realisasi2
         )
-        ON CONFLICT (kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek, tahun) DO UPDATE SET
-            anggaran1 = EXCLUDED.anggaran1,
-            anggaran2 = EXCLUDED.anggaran2,
-            realisasi1 = EXCLUDED.realisasi1,
-            realisasi2 = EXCLUDED.realisasi2;`
+        ` + anggaranDetailConflictClause + `;`
 )
 
-func (s *dbStorer) StoreAnggaranDetails(ctx context.Context, details []domain.AnggaranDetail) error {
-	tx, err := s.db.BeginTxx(ctx, nil)
+// WriteStats menghitung hasil upsert: baris baru, baris yang berubah, dan
+// baris yang sudah sama persis sehingga tidak ditulis ulang.
+type WriteStats struct {
+	Inserted  int64
+	Updated   int64
+	Unchanged int64
+}
+
+// Add menjumlahkan o ke dalam ws.
+func (ws *WriteStats) Add(o WriteStats) {
+	ws.Inserted += o.Inserted
+	ws.Updated += o.Updated
+	ws.Unchanged += o.Unchanged
+}
+
+// StoreAnggaranDetails menyimpan data memakai mode yang dikonfigurasi,
+// dengan satu transaksi untuk setiap chunk BatchSize baris.
+func (s *dbStorer) StoreAnggaranDetails(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error) {
+	var stats WriteStats
+	for start := 0; start < len(details); start += s.opts.BatchSize {
+		end := min(start+s.opts.BatchSize, len(details))
+		chunkStats, err := s.storeInTx(ctx, details[start:end])
+		if err != nil {
+			return stats, err
+		}
+		stats.Add(chunkStats)
+	}
+	return stats, nil
+}
+
+// storeInTx menulis details di dalam satu transaksi baru lalu meng-commit-nya.
+func (s *dbStorer) storeInTx(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error) {
+	ctx, cancel := s.txContext(ctx)
+	defer cancel()
+
+	tx, mode, err := s.begin(ctx)
 	if err != nil {
-		return &customErrors.ErrDBOperationFailed{Operation: "begin_transaction", Err: err}
+		return WriteStats{}, err
 	}
 	defer tx.Rollback() // Aman untuk dipanggil meskipun sudah di-commit.
 
-	for _, detail := range details {
-		if _, err := tx.NamedExecContext(ctx, upsertAnggaranDetailQuery, detail); err != nil {
-			return &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
-		}
+	stats, err := s.writeDetails(ctx, tx, mode, details)
+	if err != nil {
+		return WriteStats{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return &customErrors.ErrDBOperationFailed{Operation: "commit_transaction", Err: err}
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: "commit_transaction", Err: err}
 	}
+	return stats, nil
+}
 
-	return nil
+// upsertRows menjalankan satu upsert per baris di dalam tx. Upsert yang
+// dilewati karena tidak ada perubahan tidak mengembalikan baris.
+func upsertRows(ctx context.Context, tx *sqlx.Tx, details []domain.AnggaranDetail) (WriteStats, error) {
+	var stats WriteStats
+	for _, detail := range details {
+		rows, err := sqlx.NamedQueryContext(ctx, tx, upsertAnggaranDetailQuery, detail)
+		if err != nil {
+			return stats, &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
+		}
+		returned := false
+		for rows.Next() {
+			var inserted bool
+			if err := rows.Scan(&inserted); err != nil {
+				rows.Close()
+				return stats, &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
+			}
+			returned = true
+			if inserted {
+				stats.Inserted++
+			} else {
+				stats.Updated++
+			}
+		}
+		if err := rows.Close(); err != nil {
+			return stats, &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
+		}
+		if err := rows.Err(); err != nil {
+			return stats, &customErrors.ErrDBOperationFailed{Operation: "upsert_post", Err: err}
+		}
+		if !returned {
+			stats.Unchanged++
+		}
+	}
+	return stats, nil
+}
+
+// execCountedUpsert menjalankan upsert set-based (query harus diakhiri klausa
+// RETURNING dari anggaranDetailConflictClause, tanpa titik koma) dan
+// menghitung WriteStats dari total baris yang dikirim.
+func execCountedUpsert(ctx context.Context, tx *sqlx.Tx, operation, query string, total int, args ...interface{}) (WriteStats, error) {
+	counted := `WITH upserted AS (` + query + `)
+        SELECT count(*) FILTER (WHERE inserted), count(*) FILTER (WHERE NOT inserted) FROM upserted`
+
+	var stats WriteStats
+	if err := tx.QueryRowxContext(ctx, counted, args...).Scan(&stats.Inserted, &stats.Updated); err != nil {
+		return WriteStats{}, &customErrors.ErrDBOperationFailed{Operation: operation, Err: err}
+	}
+	stats.Unchanged = int64(total) - stats.Inserted - stats.Updated
+	return stats, nil
 }
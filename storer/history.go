@@ -0,0 +1,210 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NaturalKey adalah kunci unik satu baris siskeudes_detail_anggaran, dalam
+// format yang tersimpan di tabel (kd_kab = "kd_prov.kd_kab", dst.).
+type NaturalKey struct {
+	Tahun         string  `db:"tahun"`
+	KodeProvinsi  string  `db:"kd_prov"`
+	KodeKabupaten string  `db:"kd_kab"`
+	KodeKecamatan string  `db:"kd_kec"`
+	KodeDesa      string  `db:"kd_desa"`
+	IDKegiatan    *string `db:"id_keg"`
+	KodeSubRinci  string  `db:"kd_subrinci"`
+	Akun          string  `db:"akun"`
+	Obyek         string  `db:"obyek"`
+}
+
+// HistoryEntry adalah satu versi nilai anggaran/realisasi untuk sebuah NaturalKey.
+type HistoryEntry struct {
+	NaturalKey
+	Anggaran1  float64    `db:"anggaran1"`
+	Anggaran2  float64    `db:"anggaran2"`
+	Realisasi1 float64    `db:"realisasi1"`
+	Realisasi2 float64    `db:"realisasi2"`
+	ValidFrom  time.Time  `db:"valid_from"`
+	ValidTo    *time.Time `db:"valid_to"`    // nil berarti versi yang sedang berlaku
+	SyncRunID  *string    `db:"sync_run_id"` // Run yang menggantikan versi ini
+}
+
+// historyChunkSize menjaga SELECT versi saat ini (9 parameter per key) dan
+// INSERT riwayat (16 parameter per baris) di bawah batas 65535 parameter.
+const historyChunkSize = 65535 / 16
+
+// HistoryStore mendefinisikan kontrak untuk membaca riwayat perubahan nilai.
+type HistoryStore interface {
+	GetAnggaranTimeline(ctx context.Context, key NaturalKey) ([]HistoryEntry, error)
+}
+
+const (
+	historyKeyMatch = `h.tahun = t.tahun AND h.kd_prov = t.kd_prov AND h.kd_kab = t.kd_kab
+            AND h.kd_kec = t.kd_kec AND h.kd_desa = t.kd_desa
+            AND h.id_keg IS NOT DISTINCT FROM t.id_keg AND h.kd_subrinci = t.kd_subrinci
+            AND h.akun = t.akun AND h.obyek = t.obyek`
+
+	// Versi saat ini berlaku sejak versi sebelumnya berakhir; untuk baris yang
+	// belum punya riwayat dipakai values_changed_at, bukan updated_at yang ikut
+	// bergeser saat kolom deskriptif (nama dsb.) berubah.
+	currentVersionColumns = `t.tahun, t.kd_prov, t.kd_kab, t.kd_kec, t.kd_desa, t.id_keg,
+            t.kd_subrinci, t.akun, t.obyek,
+            t.anggaran1, t.anggaran2, t.realisasi1, t.realisasi2,
+            COALESCE((SELECT max(h.valid_to) FROM siskeudes_detail_anggaran_history h
+                WHERE ` + historyKeyMatch + `), t.values_changed_at) AS valid_from`
+
+	insertHistoryQuery = `INSERT INTO siskeudes_detail_anggaran_history (
+            tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek,
+            anggaran1, anggaran2, realisasi1, realisasi2, valid_from, valid_to, sync_run_id
+        ) VALUES (
+            :tahun, :kd_prov, :kd_kab, :kd_kec, :kd_desa, :id_keg, :kd_subrinci, :akun, :obyek,
+            :anggaran1, :anggaran2, :realisasi1, :realisasi2, :valid_from, :valid_to, :sync_run_id
+        )`
+
+	timelineQuery = `SELECT tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek,
+            anggaran1, anggaran2, realisasi1, realisasi2, valid_from, valid_to, sync_run_id
+        FROM siskeudes_detail_anggaran_history t
+        WHERE tahun = $1 AND kd_prov = $2 AND kd_kab = $3 AND kd_kec = $4 AND kd_desa = $5
+            AND id_keg IS NOT DISTINCT FROM $6 AND kd_subrinci = $7 AND akun = $8 AND obyek = $9
+        UNION ALL
+        SELECT ` + currentVersionColumns + `, NULL::timestamptz, NULL::text
+        FROM siskeudes_detail_anggaran t
+        WHERE tahun = $1 AND kd_prov = $2 AND kd_kab = $3 AND kd_kec = $4 AND kd_desa = $5
+            AND id_keg IS NOT DISTINCT FROM $6 AND kd_subrinci = $7 AND akun = $8 AND obyek = $9
+        ORDER BY valid_from`
+)
+
+// GetAnggaranTimeline mengembalikan semua versi nilai untuk key, urut dari yang
+// paling lama. Elemen terakhir (ValidTo nil) adalah nilai yang sedang berlaku.
+func (s *dbStorer) GetAnggaranTimeline(ctx context.Context, key NaturalKey) ([]HistoryEntry, error) {
+	var timeline []HistoryEntry
+	err := s.db.SelectContext(ctx, &timeline, timelineQuery,
+		key.Tahun, key.KodeProvinsi, key.KodeKabupaten, key.KodeKecamatan, key.KodeDesa,
+		key.IDKegiatan, key.KodeSubRinci, key.Akun, key.Obyek)
+	if err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_timeline", Err: err}
+	}
+	return timeline, nil
+}
+
+// recordHistory menyalin versi lama dari baris yang nilai anggaran/realisasinya
+// akan berubah oleh details ke tabel riwayat. Harus dipanggil di tx yang sama
+// sebelum upsert. id_keg NULL dicocokkan seperti nilai lain, sama dengan
+// unique index natural key (migrasi 0008).
+func recordHistory(ctx context.Context, tx *sqlx.Tx, details []domain.AnggaranDetail, runID string) error {
+	incoming := make(map[historyKey]domain.AnggaranDetail, len(details))
+	for _, d := range details {
+		incoming[detailKey(d)] = d
+	}
+	if len(incoming) == 0 {
+		return nil
+	}
+
+	query, args := buildCurrentVersionQuery(incoming)
+	var current []HistoryEntry
+	if err := sqlx.SelectContext(ctx, tx, &current, query, args...); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "select_current_versions", Err: err}
+	}
+
+	now := time.Now()
+	var changed []HistoryEntry
+	for _, old := range current {
+		d, ok := incoming[naturalKeyOf(old.NaturalKey)]
+		if !ok {
+			continue
+		}
+		if old.Anggaran1 == d.Anggaran1 && old.Anggaran2 == d.Anggaran2 &&
+			old.Realisasi1 == d.Realisasi1 && old.Realisasi2 == d.Realisasi2 {
+			continue
+		}
+		old.ValidTo = &now
+		if runID != "" {
+			old.SyncRunID = &runID
+		}
+		changed = append(changed, old)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	if _, err := tx.NamedExecContext(ctx, insertHistoryQuery, changed); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "insert_history", Err: err}
+	}
+	return nil
+}
+
+// buildCurrentVersionQuery menyusun SELECT versi saat ini untuk semua key
+// dengan join ke daftar VALUES. Perbandingan tuple IN tidak dipakai karena
+// id_keg NULL tidak pernah cocok dengan "=".
+func buildCurrentVersionQuery(keys map[historyKey]domain.AnggaranDetail) (string, []interface{}) {
+	const keyCols = 9
+	args := make([]interface{}, 0, len(keys)*keyCols)
+
+	var b strings.Builder
+	b.WriteString("SELECT ")
+	b.WriteString(currentVersionColumns)
+	b.WriteString(`
+        FROM siskeudes_detail_anggaran t
+        JOIN (VALUES `)
+	i := 0
+	for k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for c := 0; c < keyCols; c++ {
+			if c > 0 {
+				b.WriteString(", ")
+			}
+			// Tipe ditulis eksplisit agar parameter NULL tetap bertipe text
+			fmt.Fprintf(&b, "$%d::text", i*keyCols+c+1)
+		}
+		b.WriteByte(')')
+		var keg interface{}
+		if !k.noKeg {
+			keg = k.keg
+		}
+		args = append(args, k.tahun, k.prov, k.kab, k.kec, k.desa, keg, k.subrinci, k.akun, k.obyek)
+		i++
+	}
+	// Kondisi join sama dengan pencocokan tabel kunci rekonsiliasi (alias k)
+	b.WriteString(`) AS k (tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek)
+        ON ` + seenKeyMatch)
+
+	return b.String(), args
+}
+
+// historyKey adalah versi NaturalKey yang bisa dipakai sebagai key map
+// (id_keg tidak berupa pointer; noKeg menandai id_keg NULL).
+type historyKey struct {
+	tahun, prov, kab, kec, desa, keg, subrinci, akun, obyek string
+	noKeg                                                   bool
+}
+
+// naturalKeyOf mengubah NaturalKey menjadi historyKey.
+func naturalKeyOf(k NaturalKey) historyKey {
+	key := historyKey{tahun: k.Tahun, prov: k.KodeProvinsi, kab: k.KodeKabupaten, kec: k.KodeKecamatan,
+		desa: k.KodeDesa, subrinci: k.KodeSubRinci, akun: k.Akun, obyek: k.Obyek, noKeg: k.IDKegiatan == nil}
+	if k.IDKegiatan != nil {
+		key.keg = *k.IDKegiatan
+	}
+	return key
+}
+
+// detailKey mengembalikan kunci natural satu baris hasil fetch.
+func detailKey(d domain.AnggaranDetail) historyKey {
+	return naturalKeyOf(NaturalKey{
+		Tahun: d.Tahun, KodeProvinsi: d.KodeProvinsi, KodeKabupaten: d.KodeKabupaten,
+		KodeKecamatan: d.KodeKecamatan, KodeDesa: d.KodeDesa, IDKegiatan: d.IDKegiatan,
+		KodeSubRinci: d.KodeSubRinci, Akun: d.Akun, Obyek: d.Obyek,
+	})
+}
@@ -0,0 +1,89 @@
+package storer
+
+import (
+	"context"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+const seenKeysTable = "sync_seen_keys"
+
+const (
+	// Tabel sementara berisi kunci natural semua baris yang ditulis selama
+	// satu sesi wilayah; otomatis dihapus saat transaksi selesai.
+	createSeenKeysTableQuery = `CREATE TEMP TABLE IF NOT EXISTS ` + seenKeysTable + ` ON COMMIT DROP AS
+        SELECT tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek
+        FROM siskeudes_detail_anggaran WITH NO DATA;`
+
+	// IS NOT DISTINCT FROM dipakai agar id_keg yang NULL tetap dianggap cocok.
+	seenKeyMatch = `k.tahun = t.tahun AND k.kd_prov = t.kd_prov AND k.kd_kab = t.kd_kab
+            AND k.kd_kec = t.kd_kec AND k.kd_desa = t.kd_desa
+            AND k.id_keg IS NOT DISTINCT FROM t.id_keg AND k.kd_subrinci = t.kd_subrinci
+            AND k.akun = t.akun AND k.obyek = t.obyek`
+
+	// Filter scope untuk $1..$5 (RegionScope.args); kd_kec/kd_desa kosong berarti semua.
+	scopeMatch = `t.tahun = $1 AND t.kd_prov = $2 AND t.kd_kab = $3
+        AND ($4 = '' OR t.kd_kec = $4) AND ($5 = '' OR t.kd_desa = $5)`
+
+	reconcileDeleteQuery = `DELETE FROM siskeudes_detail_anggaran t
+        WHERE ` + scopeMatch + `
+        AND NOT EXISTS (SELECT 1 FROM ` + seenKeysTable + ` k WHERE ` + seenKeyMatch + `);`
+
+	reconcileSoftDeleteQuery = `UPDATE siskeudes_detail_anggaran t SET deleted_at = now()
+        WHERE ` + scopeMatch + ` AND t.deleted_at IS NULL
+        AND NOT EXISTS (SELECT 1 FROM ` + seenKeysTable + ` k WHERE ` + seenKeyMatch + `);`
+
+	// Baris yang sebelumnya di-soft-delete lalu muncul lagi di API dihidupkan kembali.
+	reviveSoftDeletedQuery = `UPDATE siskeudes_detail_anggaran t SET deleted_at = NULL
+        WHERE ` + scopeMatch + ` AND t.deleted_at IS NOT NULL
+        AND EXISTS (SELECT 1 FROM ` + seenKeysTable + ` k WHERE ` + seenKeyMatch + `);`
+)
+
+// copySeenKeys mencatat kunci natural details ke tabel sementara di dalam tx.
+func copySeenKeys(ctx context.Context, tx *sqlx.Tx, details []domain.AnggaranDetail) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(seenKeysTable,
+		"tahun", "kd_prov", "kd_kab", "kd_kec", "kd_desa", "id_keg", "kd_subrinci", "akun", "obyek"))
+	if err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "prepare_copy_seen_keys", Err: err}
+	}
+	for _, d := range details {
+		if _, err := stmt.ExecContext(ctx, d.Tahun, d.KodeProvinsi, d.KodeKabupaten, d.KodeKecamatan, d.KodeDesa, d.IDKegiatan, d.KodeSubRinci, d.Akun, d.Obyek); err != nil {
+			stmt.Close()
+			return &customErrors.ErrDBOperationFailed{Operation: "copy_seen_keys", Err: err}
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return &customErrors.ErrDBOperationFailed{Operation: "copy_seen_keys", Err: err}
+	}
+	if err := stmt.Close(); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "copy_seen_keys", Err: err}
+	}
+	return nil
+}
+
+// reconcileDelete menghapus baris dalam scope yang tidak tercatat di tabel kunci.
+func reconcileDelete(ctx context.Context, tx *sqlx.Tx, scope RegionScope) (int64, error) {
+	res, err := tx.ExecContext(ctx, reconcileDeleteQuery, scope.args()...)
+	if err != nil {
+		return 0, &customErrors.ErrDBOperationFailed{Operation: "reconcile_delete", Err: err}
+	}
+	return res.RowsAffected()
+}
+
+// reconcileSoftDelete menandai baris yang hilang dengan deleted_at dan
+// menghidupkan kembali baris yang muncul lagi.
+func reconcileSoftDelete(ctx context.Context, tx *sqlx.Tx, scope RegionScope) (int64, error) {
+	if _, err := tx.ExecContext(ctx, reviveSoftDeletedQuery, scope.args()...); err != nil {
+		return 0, &customErrors.ErrDBOperationFailed{Operation: "reconcile_revive", Err: err}
+	}
+	res, err := tx.ExecContext(ctx, reconcileSoftDeleteQuery, scope.args()...)
+	if err != nil {
+		return 0, &customErrors.ErrDBOperationFailed{Operation: "reconcile_soft_delete", Err: err}
+	}
+	return res.RowsAffected()
+}
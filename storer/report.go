@@ -0,0 +1,140 @@
+package storer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RegionSummary adalah jumlah baris dan total nilai anggaran/realisasi
+// dalam satu scope, dipakai untuk membandingkan database dengan API.
+type RegionSummary struct {
+	Records    int64   `db:"records"`
+	Anggaran1  float64 `db:"anggaran1"`
+	Anggaran2  float64 `db:"anggaran2"`
+	Realisasi1 float64 `db:"realisasi1"`
+	Realisasi2 float64 `db:"realisasi2"`
+}
+
+// Add menambahkan satu baris detail ke ringkasan.
+func (r *RegionSummary) Add(d domain.AnggaranDetail) {
+	r.Records++
+	r.Anggaran1 += d.Anggaran1
+	r.Anggaran2 += d.Anggaran2
+	r.Realisasi1 += d.Realisasi1
+	r.Realisasi2 += d.Realisasi2
+}
+
+// ExportFilter membatasi baris yang diekspor. Tahun minimal berisi satu
+// tahun; KodeProvinsi kosong berarti semua provinsi. Baris yang sudah di-soft-delete tidak ikut diekspor.
+type ExportFilter struct {
+	Tahun        []string
+	KodeProvinsi []string
+}
+
+// ReportStore mendefinisikan kontrak baca untuk subcommand status, verify dan export.
+type ReportStore interface {
+	GetRecentRuns(ctx context.Context, limit int) ([]SyncRun, error)
+	SummarizeRegion(ctx context.Context, scope RegionScope) (RegionSummary, error)
+	ExportAnggaranDetails(ctx context.Context, filter ExportFilter, handle func(domain.AnggaranDetail) error) error
+}
+
+const (
+	recentRunsQuery = `SELECT run_id, tahun, provinsi, status, version, started_at, finished_at
+        FROM sync_runs ORDER BY started_at DESC LIMIT $1;`
+
+	summarizeRegionQuery = `SELECT count(*) AS records,
+            COALESCE(sum(anggaran1), 0) AS anggaran1,
+            COALESCE(sum(anggaran2), 0) AS anggaran2,
+            COALESCE(sum(realisasi1), 0) AS realisasi1,
+            COALESCE(sum(realisasi2), 0) AS realisasi2
+        FROM siskeudes_detail_anggaran t
+        WHERE ` + scopeMatch + ` AND deleted_at IS NULL;`
+)
+
+// GetRecentRuns mengambil sejumlah limit run terakhir, yang terbaru lebih dulu.
+func (s *dbStorer) GetRecentRuns(ctx context.Context, limit int) ([]SyncRun, error) {
+	var runs []SyncRun
+	if err := s.db.SelectContext(ctx, &runs, recentRunsQuery, limit); err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_recent_runs", Err: err}
+	}
+	return runs, nil
+}
+
+// SummarizeRegion menghitung ringkasan baris aktif dalam scope.
+func (s *dbStorer) SummarizeRegion(ctx context.Context, scope RegionScope) (RegionSummary, error) {
+	var summary RegionSummary
+	err := s.db.GetContext(ctx, &summary, summarizeRegionQuery, scope.args()...)
+	if err != nil {
+		return summary, &customErrors.ErrDBOperationFailed{Operation: "summarize_region", Err: err}
+	}
+	return summary, nil
+}
+
+// ExportAnggaranDetails membaca baris sesuai filter satu per satu dan
+// menyerahkannya ke handle, sehingga ekspor besar tidak ditahan di memori.
+func (s *dbStorer) ExportAnggaranDetails(ctx context.Context, filter ExportFilter, handle func(domain.AnggaranDetail) error) error {
+	query := `SELECT ` + strings.Join(anggaranDetailColumns, ", ") + ` FROM siskeudes_detail_anggaran
+        WHERE tahun IN (?) AND deleted_at IS NULL`
+	args := []interface{}{filter.Tahun}
+	if len(filter.KodeProvinsi) > 0 {
+		query += ` AND kd_prov IN (?)`
+		args = append(args, filter.KodeProvinsi)
+	}
+	query += ` ORDER BY tahun, kd_prov, kd_kab, kd_kec, kd_desa, id_keg, kd_subrinci, akun, obyek`
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "export_details", Err: err}
+	}
+
+	rows, err := s.db.QueryxContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "export_details", Err: err}
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var detail domain.AnggaranDetail
+		if err := rows.StructScan(&detail); err != nil {
+			return &customErrors.ErrDBOperationFailed{Operation: "export_details", Err: err}
+		}
+		if err := handle(detail); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "export_details", Err: err}
+	}
+	return nil
+}
+
+// ExportColumns mengembalikan nama kolom dengan urutan yang sama seperti ExportRecord.
+func ExportColumns() []string {
+	return append([]string(nil), anggaranDetailColumns...)
+}
+
+// ExportRecord memformat satu baris detail sebagai string per kolom, misal
+// untuk CSV. Nilai NULL menjadi string kosong.
+func ExportRecord(d domain.AnggaranDetail) []string {
+	values := anggaranDetailCopyValues(d)
+	record := make([]string, len(values))
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			record[i] = v
+		case *string:
+			if v != nil {
+				record[i] = *v
+			}
+		case float64:
+			record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return record
+}
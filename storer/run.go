@@ -0,0 +1,109 @@
+package storer
+
+import (
+	"context"
+	"time"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+
+	"github.com/lib/pq"
+)
+
+// Status yang mungkin untuk sebuah run maupun wilayah di dalamnya.
+const (
+	RunRunning            = "running"
+	RunCompleted          = "completed"
+	RunCompletedWithError = "completed_with_errors"
+	RunFailed             = "failed"
+	RunInterrupted        = "interrupted"
+)
+
+// SyncRun adalah satu pemanggilan Synchronize.
+type SyncRun struct {
+	RunID      string         `db:"run_id"`
+	Tahun      pq.Int64Array  `db:"tahun"`
+	Provinsi   pq.StringArray `db:"provinsi"`
+	Status     string         `db:"status"`
+	Version    string         `db:"version"`
+	StartedAt  time.Time      `db:"started_at"`
+	FinishedAt *time.Time     `db:"finished_at"`
+}
+
+// SyncRunRegion adalah hasil satu unit wilayah (kabupaten/kota, kecamatan atau
+// desa) untuk satu tahun di dalam sebuah run.
+type SyncRunRegion struct {
+	RunID         string    `db:"run_id"`
+	Tahun         int       `db:"tahun"`
+	KodeProvinsi  string    `db:"kd_prov"`
+	KodeKabupaten string    `db:"kd_kab"`
+	KodeKecamatan string    `db:"kd_kec"`
+	KodeDesa      string    `db:"kd_desa"`
+	Status        string    `db:"status"`
+	PageCount     int       `db:"page_count"`
+	FetchedCount  int       `db:"fetched_count"`
+	InsertedCount int64     `db:"inserted_count"`
+	UpdatedCount  int64     `db:"updated_count"`
+	DeletedCount  int64     `db:"deleted_count"`
+	DurationMS    int64     `db:"duration_ms"`
+	ErrorMessage  *string   `db:"error_message"`
+	StartedAt     time.Time `db:"started_at"`
+	FinishedAt    time.Time `db:"finished_at"`
+}
+
+// RunStore mendefinisikan kontrak untuk mencatat audit setiap run sinkronisasi.
+type RunStore interface {
+	StartRun(ctx context.Context, run SyncRun) error
+	FinishRun(ctx context.Context, runID, status string, finishedAt time.Time) error
+	SaveRunRegion(ctx context.Context, region SyncRunRegion) error
+}
+
+const (
+	insertSyncRunQuery = `INSERT INTO sync_runs (
+            run_id, tahun, provinsi, status, version, started_at, finished_at
+        ) VALUES (
+            :run_id, :tahun, :provinsi, :status, :version, :started_at, :finished_at
+        );`
+
+	finishSyncRunQuery = `UPDATE sync_runs SET status = $2, finished_at = $3 WHERE run_id = $1;`
+
+	// Wilayah yang diproses ulang dalam run yang sama (misal pass retry) menimpa hasil sebelumnya.
+	upsertSyncRunRegionQuery = `INSERT INTO sync_run_regions (
+            run_id, tahun, kd_prov, kd_kab, kd_kec, kd_desa, status, page_count, fetched_count, inserted_count,
+            updated_count, deleted_count, duration_ms, error_message, started_at, finished_at
+        ) VALUES (
+            :run_id, :tahun, :kd_prov, :kd_kab, :kd_kec, :kd_desa, :status, :page_count, :fetched_count, :inserted_count,
+            :updated_count, :deleted_count, :duration_ms, :error_message, :started_at, :finished_at
+        )
+        ON CONFLICT (run_id, tahun, kd_prov, kd_kab, kd_kec, kd_desa) DO UPDATE SET
+            status = EXCLUDED.status,
+            page_count = EXCLUDED.page_count,
+            fetched_count = EXCLUDED.fetched_count,
+            inserted_count = EXCLUDED.inserted_count,
+            updated_count = EXCLUDED.updated_count,
+            deleted_count = EXCLUDED.deleted_count,
+            duration_ms = EXCLUDED.duration_ms,
+            error_message = EXCLUDED.error_message,
+            started_at = EXCLUDED.started_at,
+            finished_at = EXCLUDED.finished_at;`
+)
+
+func (s *dbStorer) StartRun(ctx context.Context, run SyncRun) error {
+	if _, err := s.db.NamedExecContext(ctx, insertSyncRunQuery, run); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "start_run", Err: err}
+	}
+	return nil
+}
+
+func (s *dbStorer) FinishRun(ctx context.Context, runID, status string, finishedAt time.Time) error {
+	if _, err := s.db.ExecContext(ctx, finishSyncRunQuery, runID, status, finishedAt); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "finish_run", Err: err}
+	}
+	return nil
+}
+
+func (s *dbStorer) SaveRunRegion(ctx context.Context, region SyncRunRegion) error {
+	if _, err := s.db.NamedExecContext(ctx, upsertSyncRunRegionQuery, region); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "save_run_region", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/migrations"
+)
+
+// CheckSchema memastikan database sudah memakai versi skema terbaru yang
+// di-embed di binary. Skema dibuat oleh subcommand migrate, bukan oleh storer,
+// dan query storer bisa memakai tabel, kolom maupun index dari migrasi mana
+// pun, jadi migrasi yang tertinggal harus ketahuan sebelum sync dimulai, bukan
+// sebagai error SQL (atau ON CONFLICT yang salah sasaran) di tengah wilayah.
+// Migrasi yang belum diterapkan dilaporkan sekaligus sebagai
+// *customErrors.ErrSchemaOutdated.
+func (s *dbStorer) CheckSchema(ctx context.Context) error {
+	migrator, err := migrations.New(s.db)
+	if err != nil {
+		return fmt.Errorf("load migrations: %w", err)
+	}
+	pending, err := migrator.Pending(ctx)
+	if err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	missing := make([]string, 0, len(pending))
+	for _, mig := range pending {
+		missing = append(missing, fmt.Sprintf("migrasi %04d_%s", mig.Version, mig.Name))
+	}
+	return &customErrors.ErrSchemaOutdated{Missing: missing}
+}
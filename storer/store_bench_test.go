@@ -0,0 +1,120 @@
+package storer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/migrations"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// Benchmark penyimpanan membutuhkan PostgreSQL sungguhan. Set TEST_DATABASE_URL
+// ke database yang boleh dimigrasi, misal:
+//
+//	TEST_DATABASE_URL=postgres://postgres@localhost/synctodb_test?sslmode=disable \
+//		go test ./storer -run '^$' -bench Store -benchmem
+//
+// Setiap iterasi menulis di dalam transaksi yang di-rollback sehingga tabel
+// tetap kosong dan semua iterasi mengukur insert baru.
+const testDatabaseEnv = "TEST_DATABASE_URL"
+
+func openBenchDB(b *testing.B) *sqlx.DB {
+	b.Helper()
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		b.Skipf("%s tidak di-set", testDatabaseEnv)
+	}
+	db, err := sqlx.Connect("postgres", dsn)
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		b.Fatalf("load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		b.Fatalf("migrate up: %v", err)
+	}
+	return db
+}
+
+// benchDetails membuat n baris dengan kunci natural yang berbeda-beda dalam
+// satu desa, menyerupai satu halaman hasil fetch.
+func benchDetails(n int) []domain.AnggaranDetail {
+	details := make([]domain.AnggaranDetail, n)
+	for i := range details {
+		idKeg := fmt.Sprintf("51.03.01.2001.%02d", i%50)
+		nama := "Kegiatan benchmark"
+		details[i] = domain.AnggaranDetail{
+			Tahun:         "2024",
+			KodeProvinsi:  "51",
+			NamaProvinsi:  "BALI",
+			KodeKabupaten: "51.03",
+			NamaKabupaten: "BADUNG",
+			KodeKecamatan: "51.03.01",
+			NamaKecamatan: "KUTA SELATAN",
+			KodeDesa:      "51.03.2001",
+			NamaDesa:      "BENCHMARK",
+			IDKegiatan:    &idKeg,
+			NamaKegiatan:  &nama,
+			KodeSubRinci:  fmt.Sprintf("%d", i/50),
+			KodeSumber:    "DDS",
+			Akun:          "5",
+			Obyek:         fmt.Sprintf("5.2.2.%05d", i),
+			Anggaran1:     float64(i) * 1000,
+			Anggaran2:     float64(i) * 1000,
+		}
+	}
+	return details
+}
+
+// benchBatchSize sama dengan store.batch_size bawaan sehingga benchmark
+// mengukur konfigurasi yang benar-benar dipakai.
+const benchBatchSize = 500
+
+// benchmarkStore menulis lewat RegionWriter dengan CommitPerRegion, jalur yang
+// dipakai synchronizer, termasuk pemecahan per BatchSize untuk mode row/batch.
+func benchmarkStore(b *testing.B, mode string) {
+	db := openBenchDB(b)
+	s := NewDBStorer(db, Options{Mode: mode, BatchSize: benchBatchSize, CommitMode: CommitPerRegion})
+	ctx := context.Background()
+	scope := RegionScope{Tahun: "2024", KodeProvinsi: "51", KodeKabupaten: "51.03"}
+
+	for _, rows := range []int{100, 1000, 5000} {
+		details := benchDetails(rows)
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				w, err := s.BeginRegion(ctx, scope)
+				if err != nil {
+					b.Fatalf("begin: %v", err)
+				}
+				if got := w.(*regionTxWriter).mode; got != mode {
+					w.Rollback()
+					b.Fatalf("got store mode %s, want %s", got, mode)
+				}
+				if _, err := w.Store(ctx, details); err != nil {
+					w.Rollback()
+					b.Fatalf("store: %v", err)
+				}
+				w.Rollback()
+			}
+			b.ReportMetric(float64(rows*b.N)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
+
+// BenchmarkStoreRow mengukur upsert per baris (StoreModeRow).
+func BenchmarkStoreRow(b *testing.B) { benchmarkStore(b, StoreModeRow) }
+
+// BenchmarkStoreBatch mengukur multi-row upsert (StoreModeBatch).
+func BenchmarkStoreBatch(b *testing.B) { benchmarkStore(b, StoreModeBatch) }
+
+// BenchmarkStoreCopy mengukur COPY ke tabel staging lalu merge (StoreModeCopy).
+func BenchmarkStoreCopy(b *testing.B) { benchmarkStore(b, StoreModeCopy) }
@@ -0,0 +1,53 @@
+package storer
+
+import (
+	"context"
+
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+)
+
+// Subregion adalah satu kecamatan atau desa dalam format tabel
+// (kd_kec = "kd_prov.kd_kab.kd_kec"). KodeDesa kosong untuk kecamatan.
+type Subregion struct {
+	KodeKecamatan string `db:"kd_kec"`
+	KodeDesa      string `db:"kd_desa"`
+}
+
+// SubregionStore mendefinisikan kontrak untuk menemukan kecamatan dan desa
+// dalam sebuah kabupaten. API tidak menyediakan daftar tersebut, sehingga
+// yang dipakai adalah kode yang pernah tersimpan dari sinkronisasi sebelumnya.
+type SubregionStore interface {
+	GetKnownKecamatan(ctx context.Context, scope RegionScope) ([]Subregion, error)
+	GetKnownDesa(ctx context.Context, scope RegionScope) ([]Subregion, error)
+}
+
+const (
+	// Baris yang sudah di-soft-delete tidak dihitung agar kecamatan/desa yang
+	// hilang dari API tidak terus diambil; jika muncul kembali, run tingkat
+	// kabupaten berikutnya yang menyimpannya lagi.
+	knownKecamatanQuery = `SELECT DISTINCT kd_kec, '' AS kd_desa
+        FROM siskeudes_detail_anggaran t WHERE ` + scopeMatch + ` AND t.deleted_at IS NULL
+        ORDER BY kd_kec;`
+
+	knownDesaQuery = `SELECT DISTINCT kd_kec, kd_desa
+        FROM siskeudes_detail_anggaran t WHERE ` + scopeMatch + ` AND t.deleted_at IS NULL
+        ORDER BY kd_kec, kd_desa;`
+)
+
+// GetKnownKecamatan mengambil kecamatan yang pernah tersimpan dalam scope.
+func (s *dbStorer) GetKnownKecamatan(ctx context.Context, scope RegionScope) ([]Subregion, error) {
+	var subregions []Subregion
+	if err := s.db.SelectContext(ctx, &subregions, knownKecamatanQuery, scope.args()...); err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_known_kecamatan", Err: err}
+	}
+	return subregions, nil
+}
+
+// GetKnownDesa mengambil desa yang pernah tersimpan dalam scope.
+func (s *dbStorer) GetKnownDesa(ctx context.Context, scope RegionScope) ([]Subregion, error) {
+	var subregions []Subregion
+	if err := s.db.SelectContext(ctx, &subregions, knownDesaQuery, scope.args()...); err != nil {
+		return nil, &customErrors.ErrDBOperationFailed{Operation: "get_known_desa", Err: err}
+	}
+	return subregions, nil
+}
@@ -0,0 +1,221 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RegionWriter menulis data satu wilayah yang datang bertahap (per halaman).
+// Dengan CommitPerRegion semua Store berada dalam satu transaksi yang baru
+// terlihat setelah Commit; dengan CommitPerChunk setiap Store langsung di-commit
+// per chunk dan Commit tidak melakukan apa-apa.
+type RegionWriter interface {
+	Store(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error)
+	// Reconcile menghapus (atau soft-delete) baris dalam scope yang tidak ikut
+	// di-Store selama sesi ini dan mengembalikan jumlah baris yang terdampak.
+	// Hanya aktif jika Options.Reconcile tidak off.
+	Reconcile(ctx context.Context) (int64, error)
+	Commit() error
+	// Rollback aman dipanggil setelah Commit.
+	Rollback() error
+}
+
+// RegionScope adalah batas wilayah yang ditulis oleh satu RegionWriter, dalam
+// format yang sama dengan yang tersimpan di tabel (kd_kab = "kd_prov.kd_kab",
+// kd_kec = "kd_prov.kd_kab.kd_kec"). KodeKecamatan dan KodeDesa kosong berarti
+// seluruh kabupaten atau seluruh kecamatan.
+type RegionScope struct {
+	Tahun         string
+	KodeProvinsi  string
+	KodeKabupaten string
+	KodeKecamatan string
+	KodeDesa      string
+}
+
+// args mengembalikan parameter $1..$5 untuk query yang difilter per scope.
+func (scope RegionScope) args() []interface{} {
+	return []interface{}{scope.Tahun, scope.KodeProvinsi, scope.KodeKabupaten, scope.KodeKecamatan, scope.KodeDesa}
+}
+
+// BeginRegion membuka RegionWriter sesuai CommitMode yang dikonfigurasi.
+func (s *dbStorer) BeginRegion(ctx context.Context, scope RegionScope) (RegionWriter, error) {
+	if s.opts.CommitMode != CommitPerRegion {
+		return &chunkWriter{s: s}, nil
+	}
+
+	txCtx, cancel := s.txContext(ctx)
+	tx, mode, err := s.begin(txCtx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	w := &regionTxWriter{s: s, tx: tx, mode: mode, scope: scope, txCtx: txCtx, cancel: cancel}
+	if s.opts.Reconcile != ReconcileOff {
+		if _, err := tx.ExecContext(txCtx, createSeenKeysTableQuery); err != nil {
+			w.Rollback()
+			return nil, &customErrors.ErrDBOperationFailed{Operation: "create_seen_keys", Err: err}
+		}
+	}
+	return w, nil
+}
+
+// chunkWriter meneruskan setiap Store ke StoreAnggaranDetails.
+type chunkWriter struct {
+	s *dbStorer
+}
+
+func (w *chunkWriter) Store(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error) {
+	return w.s.StoreAnggaranDetails(ctx, details)
+}
+
+func (w *chunkWriter) Reconcile(ctx context.Context) (int64, error) { return 0, nil }
+func (w *chunkWriter) Commit() error                                { return nil }
+func (w *chunkWriter) Rollback() error                              { return nil }
+
+// regionTxWriter menahan satu transaksi terbuka selama satu wilayah.
+type regionTxWriter struct {
+	s     *dbStorer
+	tx    *sqlx.Tx
+	mode  string
+	scope RegionScope
+	// txCtx membawa deadline TxTimeout; cancel dipanggil saat Commit atau Rollback
+	txCtx  context.Context
+	cancel context.CancelFunc
+}
+
+// stmtContext menambahkan deadline transaksi ke ctx pemanggil agar statement
+// yang sedang berjalan ikut dihentikan saat transaksi melewati TxTimeout.
+func (w *regionTxWriter) stmtContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := w.txCtx.Deadline(); ok {
+		return context.WithDeadline(ctx, deadline)
+	}
+	return ctx, func() {}
+}
+
+func (w *regionTxWriter) Store(ctx context.Context, details []domain.AnggaranDetail) (WriteStats, error) {
+	ctx, cancel := w.stmtContext(ctx)
+	defer cancel()
+
+	var stats WriteStats
+	chunkSize := w.s.writeChunkSize(w.mode, len(details))
+	for start := 0; start < len(details); start += chunkSize {
+		end := min(start+chunkSize, len(details))
+		chunkStats, err := w.s.writeDetails(ctx, w.tx, w.mode, details[start:end])
+		if err != nil {
+			return stats, err
+		}
+		stats.Add(chunkStats)
+	}
+	if w.s.opts.Reconcile != ReconcileOff {
+		if err := copySeenKeys(ctx, w.tx, details); err != nil {
+			return stats, err
+		}
+	}
+	return stats, nil
+}
+
+func (w *regionTxWriter) Reconcile(ctx context.Context) (int64, error) {
+	ctx, cancel := w.stmtContext(ctx)
+	defer cancel()
+
+	switch w.s.opts.Reconcile {
+	case ReconcileDelete:
+		return reconcileDelete(ctx, w.tx, w.scope)
+	case ReconcileSoft:
+		return reconcileSoftDelete(ctx, w.tx, w.scope)
+	default:
+		return 0, nil
+	}
+}
+
+func (w *regionTxWriter) Commit() error {
+	defer w.cancel()
+	if err := w.tx.Commit(); err != nil {
+		return &customErrors.ErrDBOperationFailed{Operation: "commit_transaction", Err: err}
+	}
+	return nil
+}
+
+func (w *regionTxWriter) Rollback() error {
+	defer w.cancel()
+	if err := w.tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return &customErrors.ErrDBOperationFailed{Operation: "rollback_transaction", Err: err}
+	}
+	return nil
+}
+
+// txContext menurunkan context untuk satu transaksi dengan batas TxTimeout.
+func (s *dbStorer) txContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.opts.TxTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.opts.TxTimeout)
+}
+
+// begin membuka transaksi dan menyiapkan tabel staging untuk mode copy.
+// Jika tabel staging tidak bisa dibuat (misal hak akses TEMP dicabut),
+// transaksi dibuka ulang dan mode copy jatuh kembali ke mode batch, yang
+// juga menulis per chunk tanpa membutuhkan tabel sementara.
+func (s *dbStorer) begin(ctx context.Context) (*sqlx.Tx, string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, "", &customErrors.ErrDBOperationFailed{Operation: "begin_transaction", Err: err}
+	}
+	if s.opts.Mode != StoreModeCopy {
+		return tx, s.opts.Mode, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, createStagingTableQuery); err != nil {
+		tx.Rollback()
+		s.stagingWarning.Do(func() {
+			logging.Warnf(s.opts.Logger, "Peringatan: %v: %v, mode copy memakai batch upsert.", errStagingUnavailable, err)
+		})
+
+		tx, err = s.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return nil, "", &customErrors.ErrDBOperationFailed{Operation: "begin_transaction", Err: err}
+		}
+		return tx, StoreModeBatch, nil
+	}
+	return tx, StoreModeCopy, nil
+}
+
+// writeChunkSize mengembalikan jumlah baris per writeDetails di dalam satu
+// transaksi. COPY tidak terikat batas parameter, sehingga n baris ditulis
+// dengan satu COPY dan satu merge; mode lain dipecah per BatchSize.
+func (s *dbStorer) writeChunkSize(mode string, n int) int {
+	if mode == StoreModeCopy && n > 0 {
+		return n
+	}
+	return s.opts.BatchSize
+}
+
+// writeDetails menulis details di dalam tx memakai mode yang dipilih.
+func (s *dbStorer) writeDetails(ctx context.Context, tx *sqlx.Tx, mode string, details []domain.AnggaranDetail) (WriteStats, error) {
+	if s.opts.History {
+		// Riwayat memakai query berparameter, jadi tetap dipecah meskipun
+		// details ditulis dengan satu COPY
+		for start := 0; start < len(details); start += historyChunkSize {
+			end := min(start+historyChunkSize, len(details))
+			if err := recordHistory(ctx, tx, details[start:end], s.opts.RunID); err != nil {
+				return WriteStats{}, err
+			}
+		}
+	}
+
+	switch mode {
+	case StoreModeCopy:
+		return copyDetails(ctx, tx, details)
+	case StoreModeBatch:
+		return upsertBatch(ctx, tx, details)
+	default:
+		return upsertRows(ctx, tx, details)
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/synchronizer"
+)
+
+// runSync menjalankan subcommand "sync": mengambil data dari API dan
+// menyimpannya ke database untuk provinsi yang diminta.
+func runSync(cfg *config.Config, logger *log.Logger, args []string) int {
+	// Definisikan flag untuk command line
+	// Akan membaca flag seperti: -prov="11,12,51"
+	fs := newFlagSet("sync", "[flag]")
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (contoh: 11,12,51); digabung dengan -wilayah")
+	wilayahPtr := fs.String("wilayah", "", "Pemilih wilayah: 51.03, 35, 51.03-51.10, !35.78 atau @file (contoh: 35,!35.78,51.03)")
+	kabupatenPtr := fs.String("kab", "", "Kode kabupaten untuk memulai proses (opsional)")
+	endKabupatenPtr := fs.String("end-kab", "", "Kode kabupaten terakhir yang diproses (opsional)")
+	workersPtr := fs.Int("workers", cfg.SyncWorkers, "Jumlah kabupaten yang diproses secara paralel")
+	resumePtr := fs.Bool("resume", false, "Lewati wilayah yang sudah selesai menurut tabel sync_checkpoint")
+	failurePolicyPtr := fs.String("failure-policy", cfg.SyncFailurePolicy, "Kebijakan kegagalan: continue, fail-fast, max-failures, max-failure-ratio")
+	maxFailuresPtr := fs.Int("max-failures", cfg.SyncMaxFailures, "Jumlah wilayah gagal yang masih ditoleransi (untuk -failure-policy=max-failures)")
+	maxFailureRatioPtr := fs.Float64("max-failure-ratio", cfg.SyncMaxFailureRatio, "Rasio wilayah gagal yang masih ditoleransi, 0..1 (untuk -failure-policy=max-failure-ratio)")
+	retryPassesPtr := fs.Int("retry-passes", cfg.SyncRetryPasses, "Jumlah putaran ulang untuk wilayah yang gagal")
+	retryCooldownPtr := fs.Duration("retry-cooldown", cfg.SyncRetryCooldown, "Jeda sebelum setiap putaran ulang (contoh: 5m)")
+	runTimeoutPtr := fs.Duration("run-timeout", cfg.SyncRunTimeout, "Batas waktu seluruh run, 0 berarti tanpa batas (contoh: 6h)")
+	regionTimeoutPtr := fs.Duration("region-timeout", cfg.SyncRegionTimeout, "Batas waktu per unit wilayah, 0 berarti tanpa batas (contoh: 30m)")
+	granularityPtr := fs.String("granularity", cfg.SyncGranularity, "Unit pekerjaan dan checkpoint: kabupaten, kecamatan atau desa")
+	discoveryIntervalPtr := fs.Duration("discovery-interval", cfg.SyncDiscoveryInterval, "Jarak antar-run utuh per kabupaten untuk menemukan kecamatan/desa baru, 0 berarti hanya saat belum ada data (contoh: 168h)")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+
+	// Pastikan username dan password tidak kosong
+	if !requireCredentials(cfg, logger) {
+		return exitFatal
+	}
+	failurePolicy := synchronizer.FailurePolicy{
+		Mode:            *failurePolicyPtr,
+		MaxFailures:     *maxFailuresPtr,
+		MaxFailureRatio: *maxFailureRatioPtr,
+	}
+	if err := failurePolicy.Validate(); err != nil {
+		logging.Errorf(logger, "Error: %v", err)
+		return exitUsage
+	}
+	if err := synchronizer.ValidateGranularity(*granularityPtr); err != nil {
+		logging.Errorf(logger, "Error: %v", err)
+		return exitUsage
+	}
+	logging.Infof(logger, "Tahun anggaran: %v", cfg.APIDataTahun)
+	// Proses input dari flag
+	selection, ok := parseSelection(logger, *provinsiPtr, *wilayahPtr, *kabupatenPtr, *endKabupatenPtr)
+	if !ok {
+		return exitUsage
+	}
+	// Setup Dependencies
+	// Koneksi DB
+	db, err := connectDB(cfg)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not connect to database: %v", err)
+		return exitFatal
+	}
+	defer db.Close()
+	source, err := newWilayahSource(db, cfg, logger)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not load wilayah source: %v", err)
+		return exitFatal
+	}
+
+	// ID unik untuk run ini, dicatat di tabel riwayat dan audit run
+	runID := newRunID()
+	logging.Infof(logger, "Run ID: %s", runID)
+
+	// Pastikan semua migrasi sudah diterapkan sebelum ada data yang ditulis
+	store := newStorer(db, cfg, runID, logger)
+	if err := store.CheckSchema(context.Background()); err != nil {
+		logging.Errorf(logger, "FATAL: %v", err)
+		return exitFatal
+	}
+
+	// SIGINT/SIGTERM pertama menghentikan run secara halus, sinyal kedua keluar paksa
+	interrupt, releaseSignals := handleSignals(logger)
+	defer releaseSignals()
+
+	// Inject semua dependensi ke dalam synchronizer
+	postSync := synchronizer.NewAnggaranDetailSynchronizer(newFetcher(cfg), store, source, logger, synchronizer.Options{
+		Workers:           *workersPtr,
+		Resume:            *resumePtr,
+		RunID:             runID,
+		Version:           buildVersion(),
+		FailurePolicy:     failurePolicy,
+		RetryPasses:       *retryPassesPtr,
+		RetryCooldown:     *retryCooldownPtr,
+		RegionTimeout:     *regionTimeoutPtr,
+		Granularity:       *granularityPtr,
+		DiscoveryInterval: *discoveryIntervalPtr,
+		Interrupt:         interrupt,
+	})
+
+	// Batas waktu per wilayah, per halaman dan per transaksi diturunkan dari ctx ini
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if *runTimeoutPtr > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), *runTimeoutPtr)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+	defer cancel()
+
+	result, err := postSync.Synchronize(ctx, cfg.APIDataTahun, selection)
+	if result != nil && result.Interrupted {
+		logging.Warnf(logger, "Run %s dihentikan: %d wilayah selesai, %d gagal, %d belum diproses.",
+			result.RunID, len(result.Succeeded()), len(result.Failed()), len(result.Skipped()))
+		logging.Warnf(logger, "Untuk melanjutkan, jalankan: %s", resumeHint(os.Args))
+		return exitInterrupted
+	}
+	if err != nil {
+		var syncErr *customErrors.ErrSyncFailed
+		if errors.As(err, &syncErr) {
+			logging.Errorf(logger, "ERROR: Run %s selesai dengan kegagalan: %v", result.RunID, err)
+			return exitRegionsFailed
+		}
+		logging.Errorf(logger, "FATAL: Post synchronization process failed: %v", err)
+		return exitFatal
+	}
+
+	logging.Infof(logger, "Application finished successfully.")
+	return exitOK
+}
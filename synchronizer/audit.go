@@ -0,0 +1,82 @@
+package synchronizer
+
+import (
+	"context"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+
+	"github.com/lib/pq"
+)
+
+// Audit run ditulis dengan context.WithoutCancel agar status akhir tetap
+// tercatat meskipun run dibatalkan. Kegagalan menulis audit hanya dicatat di
+// log dan tidak menggagalkan sinkronisasi.
+
+// startRun mencatat baris sync_runs untuk run ini.
+func (s *AnggaranDetailSynchronizer) startRun(ctx context.Context, tahun []int, kodeProvinsi []string, startedAt time.Time) {
+	years := make(pq.Int64Array, len(tahun))
+	for i, t := range tahun {
+		years[i] = int64(t)
+	}
+	err := s.storer.StartRun(context.WithoutCancel(ctx), storer.SyncRun{
+		RunID:     s.opts.RunID,
+		Tahun:     years,
+		Provinsi:  kodeProvinsi,
+		Status:    storer.RunRunning,
+		Version:   s.opts.Version,
+		StartedAt: startedAt,
+	})
+	if err != nil {
+		logging.Warnf(s.log, "Peringatan: gagal mencatat awal run %s: %v", s.opts.RunID, err)
+	}
+}
+
+// finishRun memperbarui status akhir run berdasarkan hasil semua wilayah
+// dan keputusan kebijakan kegagalan.
+func (s *AnggaranDetailSynchronizer) finishRun(ctx context.Context, result *SyncResult) {
+	status := storer.RunCompleted
+	switch {
+	case result.Interrupted:
+		status = storer.RunInterrupted
+	case result.Err() != nil:
+		status = storer.RunFailed
+	case len(result.Failed()) > 0:
+		status = storer.RunCompletedWithError
+	}
+
+	if err := s.storer.FinishRun(context.WithoutCancel(ctx), s.opts.RunID, status, time.Now()); err != nil {
+		logging.Warnf(s.log, "Peringatan: gagal mencatat akhir run %s: %v", s.opts.RunID, err)
+	}
+}
+
+// recordRegion mencatat hasil satu wilayah ke sync_run_regions.
+func (s *AnggaranDetailSynchronizer) recordRegion(ctx context.Context, r RegionResult) {
+	region := storer.SyncRunRegion{
+		RunID:         s.opts.RunID,
+		Tahun:         r.Tahun,
+		KodeProvinsi:  r.Wilayah.KodeProvinsi,
+		KodeKabupaten: r.Wilayah.KodeKabupaten,
+		KodeKecamatan: r.KodeKecamatan,
+		KodeDesa:      r.KodeDesa,
+		Status:        storer.RunCompleted,
+		PageCount:     r.Pages,
+		FetchedCount:  r.Stored,
+		InsertedCount: r.Writes.Inserted,
+		UpdatedCount:  r.Writes.Updated,
+		DeletedCount:  r.Deleted,
+		DurationMS:    r.Duration.Milliseconds(),
+		StartedAt:     r.StartedAt,
+		FinishedAt:    r.StartedAt.Add(r.Duration),
+	}
+	if r.Err != nil {
+		msg := r.Err.Error()
+		region.Status = storer.RunFailed
+		region.ErrorMessage = &msg
+	}
+
+	if err := s.storer.SaveRunRegion(context.WithoutCancel(ctx), region); err != nil {
+		logging.Warnf(s.log, "Peringatan: gagal mencatat hasil %s ke audit run: %v", r.unit(), err)
+	}
+}
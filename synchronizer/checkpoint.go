@@ -0,0 +1,107 @@
+package synchronizer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// skipCompleted membuang unit tahun tahun yang checkpoint-nya sudah completed,
+// baik checkpoint unit itu sendiri maupun kecamatan atau kabupaten yang
+// memuatnya. Unit yang gagal atau terhenti di tengah (status running) diproses
+// ulang dari awal; upsert bersifat idempoten sehingga halaman yang sudah
+// tersimpan aman ditulis lagi.
+func (s *AnggaranDetailSynchronizer) skipCompleted(ctx context.Context, tahun int, units []workUnit, kodeProvinsi []string) ([]workUnit, error) {
+	checkpoints, err := s.storer.GetCheckpoints(ctx, tahun, kodeProvinsi)
+	if err != nil {
+		return nil, err
+	}
+
+	completed := make(map[workUnit]bool, len(checkpoints))
+	for _, cp := range checkpoints {
+		if cp.Status == storer.CheckpointCompleted {
+			completed[workUnit{
+				Tahun:         tahun,
+				Wilayah:       domain.Wilayah{KodeProvinsi: cp.KodeProvinsi, KodeKabupaten: cp.KodeKabupaten},
+				KodeKecamatan: cp.KodeKecamatan,
+				KodeDesa:      cp.KodeDesa,
+			}] = true
+		}
+	}
+
+	var remaining []workUnit
+	for _, unit := range units {
+		kecamatan := workUnit{Tahun: unit.Tahun, Wilayah: unit.Wilayah, KodeKecamatan: unit.KodeKecamatan}
+		kabupaten := workUnit{Tahun: unit.Tahun, Wilayah: unit.Wilayah}
+		if completed[unit] || completed[kecamatan] || completed[kabupaten] {
+			continue
+		}
+		remaining = append(remaining, unit)
+	}
+
+	logging.Infof(s.log, "Mode resume tahun %d: %d unit sudah selesai dan dilewati, %d unit tersisa.", tahun, len(units)-len(remaining), len(remaining))
+	return remaining, nil
+}
+
+// regionCheckpoint menyimpan progres satu wilayah ke storer. Kegagalan menyimpan
+// checkpoint hanya dicatat di log dan tidak menggagalkan sinkronisasi wilayah.
+type regionCheckpoint struct {
+	storer storer.Storer
+	log    *log.Logger
+	cp     storer.Checkpoint
+}
+
+func (s *AnggaranDetailSynchronizer) startCheckpoint(ctx context.Context, unit workUnit, logger *log.Logger) *regionCheckpoint {
+	now := time.Now()
+	rc := &regionCheckpoint{
+		storer: s.storer,
+		log:    logger,
+		cp: storer.Checkpoint{
+			Tahun:         unit.Tahun,
+			KodeProvinsi:  unit.Wilayah.KodeProvinsi,
+			KodeKabupaten: unit.Wilayah.KodeKabupaten,
+			KodeKecamatan: unit.KodeKecamatan,
+			KodeDesa:      unit.KodeDesa,
+			Status:        storer.CheckpointRunning,
+			StartedAt:     now,
+			UpdatedAt:     now,
+		},
+	}
+	rc.save(ctx)
+	return rc
+}
+
+// page mencatat halaman terakhir yang berhasil disimpan.
+func (rc *regionCheckpoint) page(ctx context.Context, pageURL string, recordCount int) {
+	rc.cp.LastPageURL = &pageURL
+	rc.cp.RecordCount = recordCount
+	rc.cp.UpdatedAt = time.Now()
+	rc.save(ctx)
+}
+
+// finish menandai wilayah sebagai completed, atau failed jika err tidak nil.
+func (rc *regionCheckpoint) finish(ctx context.Context, err error) {
+	now := time.Now()
+	rc.cp.Status = storer.CheckpointCompleted
+	rc.cp.ErrorMessage = nil
+	if err != nil {
+		msg := err.Error()
+		rc.cp.Status = storer.CheckpointFailed
+		rc.cp.ErrorMessage = &msg
+	}
+	rc.cp.UpdatedAt = now
+	rc.cp.FinishedAt = &now
+
+	// Tetap catat status akhir meskipun context wilayah sudah dibatalkan
+	rc.save(context.WithoutCancel(ctx))
+}
+
+func (rc *regionCheckpoint) save(ctx context.Context) {
+	if err := rc.storer.SaveCheckpoint(ctx, rc.cp); err != nil {
+		logging.Warnf(rc.log, "Peringatan: gagal menyimpan checkpoint: %v", err)
+	}
+}
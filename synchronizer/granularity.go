@@ -0,0 +1,152 @@
+package synchronizer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// Granularitas unit pekerjaan yang didukung.
+const (
+	// GranularityKabupaten mengambil satu kabupaten/kota per unit (bawaan).
+	GranularityKabupaten = "kabupaten"
+	// GranularityKecamatan memecah kabupaten menjadi satu unit per kecamatan.
+	GranularityKecamatan = "kecamatan"
+	// GranularityDesa memecah kabupaten menjadi satu unit per desa.
+	GranularityDesa = "desa"
+)
+
+// ValidateGranularity memastikan granularity dikenal; kosong berarti GranularityKabupaten.
+func ValidateGranularity(granularity string) error {
+	switch granularity {
+	case "", GranularityKabupaten, GranularityKecamatan, GranularityDesa:
+		return nil
+	default:
+		return fmt.Errorf("unknown granularity %q (want %s, %s or %s)", granularity, GranularityKabupaten, GranularityKecamatan, GranularityDesa)
+	}
+}
+
+// String memformat unit sebagai "tahun prov.kab[.kec[.desa]]" untuk log.
+func (u workUnit) String() string {
+	code := u.Wilayah.KodeProvinsi + "." + u.Wilayah.KodeKabupaten
+	for _, kode := range []string{u.KodeKecamatan, u.KodeDesa} {
+		if kode != "" {
+			code += "." + kode
+		}
+	}
+	return fmt.Sprintf("%d %s", u.Tahun, code)
+}
+
+// expandUnits memecah setiap unit kabupaten tahun tahun menjadi kecamatan
+// atau desa sesuai Options.Granularity. API tidak menyediakan daftar
+// kecamatan/desa, sehingga yang dipakai adalah kode yang pernah tersimpan.
+//
+// Kabupaten tetap diproses utuh sebagai satu unit jika belum punya kode
+// tersimpan atau jika sudah waktunya penemuan ulang (lihat
+// Options.DiscoveryInterval). Run utuh itu yang menyimpan kecamatan/desa baru,
+// sehingga pada run berikutnya mendapat unit sendiri.
+func (s *AnggaranDetailSynchronizer) expandUnits(ctx context.Context, tahun int, units []workUnit, kodeProvinsi []string) ([]workUnit, error) {
+	if s.opts.Granularity == GranularityKabupaten {
+		return units, nil
+	}
+
+	var discovered map[domain.Wilayah]time.Time
+	if s.opts.DiscoveryInterval > 0 {
+		checkpoints, err := s.storer.GetCheckpoints(ctx, tahun, kodeProvinsi)
+		if err != nil {
+			return nil, err
+		}
+		discovered = lastDiscovery(checkpoints)
+	}
+
+	var expanded []workUnit
+	unknown, due := 0, 0
+	now := time.Now()
+	for _, unit := range units {
+		if s.opts.DiscoveryInterval > 0 && discoveryDue(discovered, unit.Wilayah, s.opts.DiscoveryInterval, now) {
+			due++
+			expanded = append(expanded, unit)
+			continue
+		}
+
+		scope := regionScope(unit)
+		var subregions []storer.Subregion
+		var err error
+		if s.opts.Granularity == GranularityDesa {
+			subregions, err = s.storer.GetKnownDesa(ctx, scope)
+		} else {
+			subregions, err = s.storer.GetKnownKecamatan(ctx, scope)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(subregions) == 0 {
+			unknown++
+			expanded = append(expanded, unit)
+			continue
+		}
+
+		// Kode di tabel diawali "kd_prov.kd_kab." (lihat transformDetails);
+		// prefix ini dibuang agar kembali ke format kode API
+		prefix := scope.KodeKabupaten + "."
+		for _, sub := range subregions {
+			child := unit
+			child.KodeKecamatan = strings.TrimPrefix(sub.KodeKecamatan, prefix)
+			child.KodeDesa = strings.TrimPrefix(sub.KodeDesa, prefix)
+			expanded = append(expanded, child)
+		}
+	}
+
+	if unknown > 0 {
+		logging.Warnf(s.log, "Granularitas %s tahun %d: %d kabupaten/kota belum punya data kecamatan/desa tersimpan dan diproses utuh.", s.opts.Granularity, tahun, unknown)
+	}
+	if due > 0 {
+		logging.Infof(s.log, "Granularitas %s tahun %d: %d kabupaten/kota diproses utuh untuk menemukan kecamatan/desa baru (interval %s).", s.opts.Granularity, tahun, due, s.opts.DiscoveryInterval)
+	}
+	logging.Infof(s.log, "Granularitas %s tahun %d: %d kabupaten/kota menjadi %d unit.", s.opts.Granularity, tahun, len(units), len(expanded))
+	return expanded, nil
+}
+
+// lastDiscovery mengambil waktu selesai checkpoint tingkat kabupaten yang
+// completed, yaitu run utuh terakhir untuk setiap kabupaten.
+func lastDiscovery(checkpoints []storer.Checkpoint) map[domain.Wilayah]time.Time {
+	discovered := make(map[domain.Wilayah]time.Time)
+	for _, cp := range checkpoints {
+		if cp.KodeKecamatan != "" || cp.KodeDesa != "" || cp.Status != storer.CheckpointCompleted || cp.FinishedAt == nil {
+			continue
+		}
+		discovered[domain.Wilayah{KodeProvinsi: cp.KodeProvinsi, KodeKabupaten: cp.KodeKabupaten}] = *cp.FinishedAt
+	}
+	return discovered
+}
+
+// discoveryDue bernilai true jika kabupaten belum pernah selesai diproses utuh
+// atau run utuh terakhirnya lebih lama dari interval.
+func discoveryDue(discovered map[domain.Wilayah]time.Time, wilayah domain.Wilayah, interval time.Duration, now time.Time) bool {
+	finishedAt, ok := discovered[wilayah]
+	return !ok || now.Sub(finishedAt) >= interval
+}
+
+// checkScope memastikan data mentah dari API berada di dalam kecamatan/desa
+// yang diminta. Endpoint yang mengabaikan filter kd_kec/kd_desa akan
+// mengembalikan seluruh kabupaten; tanpa pemeriksaan ini data tersebut
+// tersimpan berkali-kali dan rekonsiliasi per unit menjadi salah.
+func checkScope(unit workUnit, details []domain.AnggaranDetail) error {
+	if unit.KodeKecamatan == "" {
+		return nil
+	}
+	for _, d := range details {
+		kec := d.KodeKecamatan
+		desa := strings.TrimSuffix(d.KodeDesa, ".")
+		if kec != unit.KodeKecamatan || (unit.KodeDesa != "" && desa != unit.KodeDesa) {
+			return &customErrors.ErrOutOfScope{Requested: unit.String(), KodeKecamatan: kec, KodeDesa: desa}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package synchronizer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+func TestDiscoveryDue(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	recent := now.Add(-24 * time.Hour)
+	old := now.Add(-8 * 24 * time.Hour)
+	checkpoints := []storer.Checkpoint{
+		{KodeProvinsi: "51", KodeKabupaten: "03", Status: storer.CheckpointCompleted, FinishedAt: &recent},
+		{KodeProvinsi: "51", KodeKabupaten: "04", Status: storer.CheckpointCompleted, FinishedAt: &old},
+		// Run utuh yang gagal atau masih berjalan tidak dihitung
+		{KodeProvinsi: "51", KodeKabupaten: "05", Status: storer.CheckpointFailed, FinishedAt: &recent},
+		{KodeProvinsi: "51", KodeKabupaten: "06", Status: storer.CheckpointRunning},
+		// Checkpoint kecamatan/desa bukan run utuh
+		{KodeProvinsi: "51", KodeKabupaten: "07", KodeKecamatan: "01", Status: storer.CheckpointCompleted, FinishedAt: &recent},
+		{KodeProvinsi: "51", KodeKabupaten: "07", KodeKecamatan: "01", KodeDesa: "2001", Status: storer.CheckpointCompleted, FinishedAt: &recent},
+	}
+	discovered := lastDiscovery(checkpoints)
+
+	tests := []struct {
+		kab  string
+		want bool
+	}{
+		{"03", false},
+		{"04", true},
+		{"05", true},
+		{"06", true},
+		{"07", true},
+		{"08", true},
+	}
+	for _, tt := range tests {
+		wilayah := domain.Wilayah{KodeProvinsi: "51", KodeKabupaten: tt.kab}
+		if got := discoveryDue(discovered, wilayah, 7*24*time.Hour, now); got != tt.want {
+			t.Errorf("discoveryDue(51.%s) = %v, want %v", tt.kab, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package synchronizer
+
+import "fmt"
+
+// Mode kebijakan kegagalan yang didukung.
+const (
+	// PolicyContinue memproses semua wilayah; run gagal jika ada satu saja yang gagal.
+	PolicyContinue = "continue"
+	// PolicyFailFast berhenti membagikan wilayah baru setelah kegagalan pertama.
+	PolicyFailFast = "fail-fast"
+	// PolicyMaxFailures berhenti dan menggagalkan run jika kegagalan melebihi MaxFailures.
+	PolicyMaxFailures = "max-failures"
+	// PolicyMaxFailureRatio berhenti dan menggagalkan run jika rasio kegagalan
+	// terhadap total wilayah melebihi MaxFailureRatio.
+	PolicyMaxFailureRatio = "max-failure-ratio"
+)
+
+// FailurePolicy menentukan kapan run dihentikan lebih awal dan kapan run
+// dianggap gagal. Berlaku untuk semua kegagalan wilayah (fetch maupun store).
+type FailurePolicy struct {
+	Mode            string
+	MaxFailures     int
+	MaxFailureRatio float64
+}
+
+// Validate memastikan mode dikenal dan ambangnya masuk akal.
+func (p FailurePolicy) Validate() error {
+	switch p.Mode {
+	case PolicyContinue, PolicyFailFast:
+		return nil
+	case PolicyMaxFailures:
+		if p.MaxFailures < 0 {
+			return fmt.Errorf("max failures must be >= 0, got %d", p.MaxFailures)
+		}
+		return nil
+	case PolicyMaxFailureRatio:
+		if p.MaxFailureRatio < 0 || p.MaxFailureRatio > 1 {
+			return fmt.Errorf("max failure ratio must be between 0 and 1, got %g", p.MaxFailureRatio)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown failure policy %q", p.Mode)
+	}
+}
+
+// exceeded bernilai true jika failed dari total wilayah sudah melewati batas
+// toleransi, sehingga run dianggap gagal.
+func (p FailurePolicy) exceeded(failed, total int) bool {
+	switch p.Mode {
+	case PolicyMaxFailures:
+		return failed > p.MaxFailures
+	case PolicyMaxFailureRatio:
+		return total > 0 && float64(failed)/float64(total) > p.MaxFailureRatio
+	default:
+		return failed > 0
+	}
+}
+
+// shouldStop bernilai true jika tidak ada gunanya memproses wilayah berikutnya.
+func (p FailurePolicy) shouldStop(failed, total int) bool {
+	return p.Mode != PolicyContinue && p.exceeded(failed, total)
+}
+
+func (p FailurePolicy) String() string {
+	switch p.Mode {
+	case PolicyMaxFailures:
+		return fmt.Sprintf("%s(%d)", p.Mode, p.MaxFailures)
+	case PolicyMaxFailureRatio:
+		return fmt.Sprintf("%s(%.2f%%)", p.Mode, p.MaxFailureRatio*100)
+	default:
+		return p.Mode
+	}
+}
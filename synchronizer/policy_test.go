@@ -0,0 +1,55 @@
+package synchronizer
+
+import "testing"
+
+func TestFailurePolicyShouldStop(t *testing.T) {
+	tests := []struct {
+		policy        FailurePolicy
+		failed, total int
+		stop, failRun bool
+	}{
+		{FailurePolicy{Mode: PolicyContinue}, 0, 10, false, false},
+		{FailurePolicy{Mode: PolicyContinue}, 9, 10, false, true},
+		{FailurePolicy{Mode: PolicyFailFast}, 0, 10, false, false},
+		{FailurePolicy{Mode: PolicyFailFast}, 1, 10, true, true},
+		{FailurePolicy{Mode: PolicyMaxFailures, MaxFailures: 2}, 2, 10, false, false},
+		{FailurePolicy{Mode: PolicyMaxFailures, MaxFailures: 2}, 3, 10, true, true},
+		{FailurePolicy{Mode: PolicyMaxFailures}, 1, 10, true, true},
+		// Rasio dihitung terhadap total wilayah, bukan wilayah yang sudah diproses
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 0.1}, 1, 10, false, false},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 0.1}, 2, 10, true, true},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 0}, 1, 1000, true, true},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 1}, 10, 10, false, false},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 0.5}, 0, 0, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.shouldStop(tt.failed, tt.total); got != tt.stop {
+			t.Errorf("%s.shouldStop(%d, %d) = %v, want %v", tt.policy, tt.failed, tt.total, got, tt.stop)
+		}
+		if got := tt.policy.exceeded(tt.failed, tt.total); got != tt.failRun {
+			t.Errorf("%s.exceeded(%d, %d) = %v, want %v", tt.policy, tt.failed, tt.total, got, tt.failRun)
+		}
+	}
+}
+
+func TestFailurePolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy FailurePolicy
+		valid  bool
+	}{
+		{FailurePolicy{Mode: PolicyContinue}, true},
+		{FailurePolicy{Mode: PolicyFailFast}, true},
+		{FailurePolicy{Mode: PolicyMaxFailures}, true},
+		{FailurePolicy{Mode: PolicyMaxFailures, MaxFailures: -1}, false},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 1}, true},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: -0.1}, false},
+		{FailurePolicy{Mode: PolicyMaxFailureRatio, MaxFailureRatio: 1.5}, false},
+		{FailurePolicy{Mode: "stop"}, false},
+		{FailurePolicy{}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err == nil) != tt.valid {
+			t.Errorf("%+v.Validate() = %v, want valid=%v", tt.policy, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package synchronizer
+
+import (
+	"time"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	customErrors "github.com/aryadiwwt/synctodb-anggarandetail/errors"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// RegionResult adalah hasil pemrosesan satu unit wilayah untuk satu tahun.
+// KodeKecamatan dan KodeDesa hanya diisi jika unitnya lebih kecil dari kabupaten.
+type RegionResult struct {
+	Tahun         int
+	Wilayah       domain.Wilayah
+	KodeKecamatan string
+	KodeDesa      string
+	Attempts      int // Jumlah putaran (utama + ulang) yang memproses wilayah ini
+	Pages         int
+	Stored        int
+	Writes        storer.WriteStats
+	Deleted       int64
+	StartedAt     time.Time
+	Duration      time.Duration
+	// Err bernilai *customErrors.ErrRegionFailed jika wilayah gagal
+	Err error
+	// Skipped bernilai true jika wilayah tidak diproses karena kebijakan
+	// kegagalan atau interupsi menghentikan run lebih awal
+	Skipped bool
+}
+
+// SyncResult adalah ringkasan satu pemanggilan Synchronize.
+type SyncResult struct {
+	RunID   string
+	Regions []RegionResult
+	Policy  FailurePolicy
+	// Stopped bernilai true jika run dihentikan lebih awal oleh Policy
+	Stopped bool
+	// Interrupted bernilai true jika run dihentikan oleh Options.Interrupt
+	// (misal SIGINT); wilayah yang belum diproses ditandai Skipped
+	Interrupted bool
+}
+
+// Succeeded mengembalikan wilayah yang berhasil disinkronkan.
+func (r *SyncResult) Succeeded() []RegionResult {
+	var ok []RegionResult
+	for _, region := range r.Regions {
+		if region.Err == nil && !region.Skipped {
+			ok = append(ok, region)
+		}
+	}
+	return ok
+}
+
+// Failed mengembalikan wilayah yang gagal disinkronkan.
+func (r *SyncResult) Failed() []RegionResult {
+	var failed []RegionResult
+	for _, region := range r.Regions {
+		if region.Err != nil {
+			failed = append(failed, region)
+		}
+	}
+	return failed
+}
+
+// Skipped mengembalikan wilayah yang tidak diproses karena run dihentikan.
+func (r *SyncResult) Skipped() []RegionResult {
+	var skipped []RegionResult
+	for _, region := range r.Regions {
+		if region.Skipped {
+			skipped = append(skipped, region)
+		}
+	}
+	return skipped
+}
+
+// PolicyExceeded bernilai true jika jumlah kegagalan melewati toleransi Policy.
+func (r *SyncResult) PolicyExceeded() bool {
+	return r.Stopped || r.Policy.exceeded(len(r.Failed()), len(r.Regions))
+}
+
+// Err menggabungkan semua kegagalan wilayah menjadi *customErrors.ErrSyncFailed
+// jika kegagalan melewati toleransi Policy, atau nil jika run dianggap berhasil.
+func (r *SyncResult) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 || !r.PolicyExceeded() {
+		return nil
+	}
+	errs := make([]error, len(failed))
+	for i, region := range failed {
+		errs[i] = region.Err
+	}
+	return &customErrors.ErrSyncFailed{Total: len(r.Regions), Errs: errs}
+}
+
+// result membuat RegionResult kosong untuk unit.
+func (u workUnit) result() RegionResult {
+	return RegionResult{Tahun: u.Tahun, Wilayah: u.Wilayah, KodeKecamatan: u.KodeKecamatan, KodeDesa: u.KodeDesa}
+}
+
+// unit mengembalikan unit pekerjaan yang menghasilkan r, untuk diproses ulang.
+func (r RegionResult) unit() workUnit {
+	return workUnit{Tahun: r.Tahun, Wilayah: r.Wilayah, KodeKecamatan: r.KodeKecamatan, KodeDesa: r.KodeDesa}
+}
+
+// regionError membungkus err sebagai kegagalan unit pada tahap stage.
+func regionError(unit workUnit, stage string, err error) error {
+	return &customErrors.ErrRegionFailed{
+		Tahun:         unit.Tahun,
+		KodeProvinsi:  unit.Wilayah.KodeProvinsi,
+		KodeKabupaten: unit.Wilayah.KodeKabupaten,
+		KodeKecamatan: unit.KodeKecamatan,
+		KodeDesa:      unit.KodeDesa,
+		Stage:         stage,
+		Err:           err,
+	}
+}
+
+// logSummary menulis ringkasan akhir dari seluruh wilayah yang diproses.
+func (s *AnggaranDetailSynchronizer) logSummary(result *SyncResult) {
+	var totalStored int
+	var totalDeleted int64
+	var totalWrites storer.WriteStats
+	for _, r := range result.Succeeded() {
+		totalStored += r.Stored
+		totalDeleted += r.Deleted
+		totalWrites.Add(r.Writes)
+	}
+	failed := result.Failed()
+	for _, r := range failed {
+		logging.Errorf(s.log, "GAGAL setelah %d percobaan: %v", r.Attempts, r.Err)
+	}
+	skipped := result.Skipped()
+	logging.Infof(s.log, "Ringkasan: %d wilayah berhasil, %d gagal, %d dilewati, total %d data disimpan (%d baru, %d berubah, %d tetap), %d data dihapus.",
+		len(result.Succeeded()), len(failed), len(skipped), totalStored, totalWrites.Inserted, totalWrites.Updated, totalWrites.Unchanged, totalDeleted)
+
+	verdict, level := "run dianggap berhasil", logging.LevelInfo
+	if result.Err() != nil {
+		verdict, level = "run dianggap gagal", logging.LevelError
+	}
+	stopped := ""
+	switch {
+	case result.Interrupted:
+		stopped = ", dihentikan oleh sinyal"
+	case result.Stopped:
+		stopped = ", dihentikan lebih awal"
+	}
+	logging.Logf(s.log, level, "Kebijakan kegagalan %s: %d dari %d wilayah gagal%s, %s.", result.Policy, len(failed), len(result.Regions), stopped, verdict)
+}
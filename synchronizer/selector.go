@@ -0,0 +1,114 @@
+package synchronizer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+)
+
+// RegionPattern cocok dengan seluruh kabupaten/kota dalam satu provinsi, atau
+// dengan rentang kode kabupaten From..To (inklusif) di provinsi tersebut.
+type RegionPattern struct {
+	KodeProvinsi string
+	// From dan To bernilai 0 untuk seluruh provinsi
+	From, To int
+}
+
+// Match bernilai true jika wilayah termasuk dalam pola.
+func (p RegionPattern) Match(wilayah domain.Wilayah) bool {
+	if wilayah.KodeProvinsi != p.KodeProvinsi {
+		return false
+	}
+	if p.From == 0 && p.To == 0 {
+		return true
+	}
+	kab, err := strconv.Atoi(wilayah.KodeKabupaten)
+	return err == nil && kab >= p.From && kab <= p.To
+}
+
+func (p RegionPattern) String() string {
+	switch {
+	case p.From == 0 && p.To == 0:
+		return p.KodeProvinsi
+	case p.From == p.To:
+		return fmt.Sprintf("%s.%02d", p.KodeProvinsi, p.From)
+	default:
+		return fmt.Sprintf("%s.%02d-%s.%02d", p.KodeProvinsi, p.From, p.KodeProvinsi, p.To)
+	}
+}
+
+// RegionSelector memilih wilayah dari daftar master. Include kosong berarti
+// semua wilayah; wilayah yang cocok dengan salah satu Exclude selalu dibuang.
+type RegionSelector struct {
+	Include []RegionPattern
+	Exclude []RegionPattern
+}
+
+// Provinsi mengembalikan kode provinsi yang perlu dibaca dari master wilayah,
+// atau nil jika semua provinsi dibutuhkan.
+func (s RegionSelector) Provinsi() []string {
+	var daftarProvinsi []string
+	seen := make(map[string]bool)
+	for _, p := range s.Include {
+		if !seen[p.KodeProvinsi] {
+			seen[p.KodeProvinsi] = true
+			daftarProvinsi = append(daftarProvinsi, p.KodeProvinsi)
+		}
+	}
+	return daftarProvinsi
+}
+
+// Match bernilai true jika wilayah dipilih.
+func (s RegionSelector) Match(wilayah domain.Wilayah) bool {
+	for _, p := range s.Exclude {
+		if p.Match(wilayah) {
+			return false
+		}
+	}
+	if len(s.Include) == 0 {
+		return true
+	}
+	for _, p := range s.Include {
+		if p.Match(wilayah) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter mengembalikan wilayah yang dipilih dengan urutan daftar asli.
+func (s RegionSelector) Filter(daftarWilayah []domain.Wilayah) []domain.Wilayah {
+	var selected []domain.Wilayah
+	for _, wilayah := range daftarWilayah {
+		if s.Match(wilayah) {
+			selected = append(selected, wilayah)
+		}
+	}
+	return selected
+}
+
+func (s RegionSelector) String() string {
+	var parts []string
+	for _, p := range s.Include {
+		parts = append(parts, p.String())
+	}
+	for _, p := range s.Exclude {
+		parts = append(parts, "!"+p.String())
+	}
+	if len(parts) == 0 {
+		return "semua wilayah"
+	}
+	return strings.Join(parts, ",")
+}
+
+// Selection menentukan wilayah yang diproses oleh Synchronize dan Verify.
+type Selection struct {
+	Selector RegionSelector
+	// StartKabupaten dan EndKabupaten (kode 2 digit, opsional) memotong daftar
+	// hasil Selector: mulai dari kemunculan pertama StartKabupaten sampai
+	// kemunculan pertama EndKabupaten berikutnya, keduanya inklusif
+	StartKabupaten string
+	EndKabupaten   string
+}
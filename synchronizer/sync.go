@@ -2,97 +2,491 @@ package synchronizer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
 	"github.com/aryadiwwt/synctodb-anggarandetail/fetcher"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
 	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+	"github.com/aryadiwwt/synctodb-anggarandetail/wilayah"
 )
 
+// Options berisi pengaturan opsional untuk AnggaranDetailSynchronizer.
+type Options struct {
+	// Workers adalah jumlah kabupaten yang diproses secara paralel (minimal 1)
+	Workers int
+	// Resume melewati wilayah yang checkpoint-nya sudah completed
+	Resume bool
+	// RunID dan Version dicatat di tabel audit sync_runs
+	RunID   string
+	Version string
+	// FailurePolicy menentukan kapan run berhenti dan kapan dianggap gagal;
+	// Mode kosong berarti PolicyContinue
+	FailurePolicy FailurePolicy
+	// RetryPasses adalah jumlah putaran tambahan untuk mengulang wilayah yang
+	// gagal setelah putaran utama, masing-masing didahului jeda RetryCooldown
+	RetryPasses   int
+	RetryCooldown time.Duration
+	// RegionTimeout membatasi waktu pemrosesan satu wilayah (fetch sampai
+	// commit); <= 0 berarti hanya dibatasi oleh context run
+	RegionTimeout time.Duration
+	// Granularity menentukan unit pekerjaan: GranularityKabupaten (bawaan),
+	// GranularityKecamatan atau GranularityDesa. Checkpoint dan audit run
+	// dicatat pada tingkat yang sama.
+	Granularity string
+	// DiscoveryInterval menentukan seberapa sering kabupaten yang sudah
+	// dipecah diproses utuh lagi untuk menemukan kecamatan/desa baru; 0
+	// berarti kabupaten hanya diproses utuh selama belum punya kode tersimpan.
+	// Tidak berpengaruh untuk GranularityKabupaten.
+	DiscoveryInterval time.Duration
+	// Interrupt, jika ditutup, menghentikan pembagian wilayah baru. Wilayah
+	// yang sedang berjalan tetap diselesaikan (atau di-rollback jika gagal)
+	// sehingga checkpoint-nya konsisten. nil berarti tidak pernah diinterupsi.
+	Interrupt <-chan struct{}
+}
+
 // PostSynchronizer mengorkestrasi proses sinkronisasi data post.
 type AnggaranDetailSynchronizer struct {
 	fetcher fetcher.Fetcher
 	storer  storer.Storer
+	wilayah wilayah.Source
 	log     *log.Logger
+	opts    Options
 }
 
-func NewAnggaranDetailSynchronizer(f fetcher.Fetcher, s storer.Storer, l *log.Logger) *AnggaranDetailSynchronizer {
+func NewAnggaranDetailSynchronizer(f fetcher.Fetcher, s storer.Storer, w wilayah.Source, l *log.Logger, opts Options) *AnggaranDetailSynchronizer {
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.FailurePolicy.Mode == "" {
+		opts.FailurePolicy.Mode = PolicyContinue
+	}
+	if opts.Granularity == "" {
+		opts.Granularity = GranularityKabupaten
+	}
 	return &AnggaranDetailSynchronizer{
 		fetcher: f,
 		storer:  s,
+		wilayah: w,
 		log:     l,
+		opts:    opts,
 	}
 }
 
-func (s *AnggaranDetailSynchronizer) Synchronize(ctx context.Context, kodeProvinsi []string, startKabupaten string) error {
-	s.log.Println("Starting Anggaran detail synchronization...")
+// workUnit adalah satu kabupaten/kota (atau kecamatan/desa di dalamnya) untuk
+// satu tahun anggaran, satuan pekerjaan yang dibagikan ke worker dan dicatat
+// di checkpoint. KodeKecamatan dan KodeDesa memakai format kode API dan
+// kosong untuk unit tingkat kabupaten atau kecamatan.
+type workUnit struct {
+	Tahun         int
+	Wilayah       domain.Wilayah
+	KodeKecamatan string
+	KodeDesa      string
+}
+
+// Synchronize menyinkronkan wilayah yang dipilih selection untuk setiap
+// tahun yang diminta. Wilayah diproses per tahun secara berurutan: semua
+// wilayah tahun pertama dibagikan lebih dulu sebelum tahun berikutnya.
+//
+// Kegagalan per wilayah dicatat di SyncResult. Options.FailurePolicy
+// menentukan kelanjutannya: PolicyContinue tetap memproses semua wilayah,
+// sedangkan mode lain berhenti membagikan wilayah baru begitu ambangnya
+// terlampaui (wilayah yang belum dibagikan ditandai Skipped). Jika ambang
+// terlampaui, kegagalan dikembalikan sebagai *customErrors.ErrSyncFailed
+// bersama result. Error lain (misal daftar wilayah tidak bisa dibaca)
+// dikembalikan dengan result nil.
+func (s *AnggaranDetailSynchronizer) Synchronize(ctx context.Context, tahun []int, selection Selection) (*SyncResult, error) {
+	logging.Infof(s.log, "Starting Anggaran detail synchronization...")
+	startedAt := time.Now()
 
-	daftarWilayah, err := s.storer.GetWilayahByProvinsi(ctx, kodeProvinsi)
+	daftarWilayah, err := s.selectRegions(ctx, selection)
 	if err != nil {
-		s.log.Fatalf("Gagal mendapatkan daftar wilayah: %v", err)
+		return nil, err
 	}
 
-	if len(daftarWilayah) == 0 {
-		s.log.Println("Tidak ada data wilayah yang ditemukan untuk diproses. Selesai.")
-		return nil
+	kodeProvinsi := selection.Selector.Provinsi()
+	var units []workUnit
+	for _, t := range tahun {
+		yearUnits := make([]workUnit, len(daftarWilayah))
+		for i, wilayah := range daftarWilayah {
+			yearUnits[i] = workUnit{Tahun: t, Wilayah: wilayah}
+		}
+		yearUnits, err = s.expandUnits(ctx, t, yearUnits, kodeProvinsi)
+		if err != nil {
+			return nil, fmt.Errorf("gagal memecah wilayah tahun %d: %w", t, err)
+		}
+		if s.opts.Resume {
+			yearUnits, err = s.skipCompleted(ctx, t, yearUnits, kodeProvinsi)
+			if err != nil {
+				return nil, fmt.Errorf("gagal membaca checkpoint tahun %d: %w", t, err)
+			}
+		}
+		units = append(units, yearUnits...)
+	}
+	result := &SyncResult{RunID: s.opts.RunID, Policy: s.opts.FailurePolicy}
+	if len(units) == 0 {
+		logging.Infof(s.log, "Tidak ada data wilayah yang ditemukan untuk diproses. Selesai.")
+		return result, nil
+	}
+
+	logging.Infof(s.log, "Akan memproses data untuk %d kabupaten/kota x %d tahun (%v), %d unit %s, dengan %d worker (kebijakan kegagalan: %s)...", len(daftarWilayah), len(tahun), tahun, len(units), s.opts.Granularity, s.opts.Workers, s.opts.FailurePolicy)
+
+	s.startRun(ctx, tahun, kodeProvinsi, startedAt)
+	result.Regions, result.Stopped, result.Interrupted = s.runWorkers(ctx, units, s.opts.FailurePolicy)
+	if !result.Stopped && !result.Interrupted {
+		s.retryFailed(ctx, result)
+	}
+	s.finishRun(ctx, result)
+	s.logSummary(result)
+
+	logging.Infof(s.log, "Semua proses sinkronisasi untuk seluruh wilayah telah selesai.")
+	return result, result.Err()
+}
+
+// selectRegions membaca daftar wilayah dari sumber wilayah untuk provinsi yang dibutuhkan lalu
+// menerapkan Selector dan batas awal/akhir kabupaten.
+func (s *AnggaranDetailSynchronizer) selectRegions(ctx context.Context, selection Selection) ([]domain.Wilayah, error) {
+	daftarWilayah, err := s.wilayah.GetWilayahByProvinsi(ctx, selection.Selector.Provinsi())
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendapatkan daftar wilayah: %w", err)
+	}
+
+	total := len(daftarWilayah)
+	daftarWilayah = selection.Selector.Filter(daftarWilayah)
+	logging.Infof(s.log, "Pemilihan wilayah %s: %d dari %d kabupaten/kota dipilih.", selection.Selector, len(daftarWilayah), total)
+
+	daftarWilayah = s.skipUntilStart(daftarWilayah, selection.StartKabupaten)
+	return s.skipAfterEnd(daftarWilayah, selection.EndKabupaten), nil
+}
+
+// skipUntilStart membuang wilayah sebelum kabupaten awal (flag -kab).
+// Urutan daftar dari storer dipertahankan, sehingga semantiknya sama dengan
+// pemrosesan berurutan: semua wilayah sebelum titik awal dilewati.
+func (s *AnggaranDetailSynchronizer) skipUntilStart(daftarWilayah []domain.Wilayah, startKabupaten string) []domain.Wilayah {
+	// Jika tidak ada flag -kab, semua wilayah diproses.
+	if startKabupaten == "" {
+		return daftarWilayah
+	}
+
+	for i, wilayah := range daftarWilayah {
+		// Jika kode kabupaten saat ini cocok dengan flag, mulai proses dari sini
+		if wilayah.KodeKabupaten == startKabupaten {
+			logging.Infof(s.log, "Titik awal ditemukan. Memulai proses dari Kabupaten: %s (melewati %d wilayah)", startKabupaten, i)
+			return daftarWilayah[i:]
+		}
+	}
+
+	logging.Infof(s.log, "Titik awal Kabupaten %s tidak ditemukan, tidak ada wilayah yang diproses.", startKabupaten)
+	return nil
+}
+
+// skipAfterEnd membuang wilayah setelah kabupaten akhir (flag -end-kab).
+// Jika kabupaten akhir tidak ditemukan, semua wilayah tetap diproses.
+func (s *AnggaranDetailSynchronizer) skipAfterEnd(daftarWilayah []domain.Wilayah, endKabupaten string) []domain.Wilayah {
+	if endKabupaten == "" {
+		return daftarWilayah
+	}
+
+	for i, wilayah := range daftarWilayah {
+		if wilayah.KodeKabupaten == endKabupaten {
+			logging.Infof(s.log, "Titik akhir ditemukan. Proses berhenti setelah Kabupaten: %s (melewati %d wilayah)", endKabupaten, len(daftarWilayah)-i-1)
+			return daftarWilayah[:i+1]
+		}
+	}
+
+	logging.Warnf(s.log, "Peringatan: Titik akhir Kabupaten %s tidak ditemukan, semua wilayah setelah titik awal diproses.", endKabupaten)
+	return daftarWilayah
+}
+
+// runWorkers membagikan unit ke sejumlah worker dan mengumpulkan hasilnya
+// sesuai urutan daftar asli. Jika kebijakan kegagalan meminta berhenti (stopped)
+// atau Options.Interrupt ditutup (interrupted), wilayah yang belum dibagikan
+// ditandai Skipped; wilayah yang sedang berjalan tetap diselesaikan.
+func (s *AnggaranDetailSynchronizer) runWorkers(ctx context.Context, units []workUnit, policy FailurePolicy) (results []RegionResult, stopped, interrupted bool) {
+	results = make([]RegionResult, len(units))
+	jobs := make(chan int)
+	stop := make(chan struct{})
+
+	var (
+		mu       sync.Mutex
+		failed   int
+		stopOnce sync.Once
+	)
+
+	var wg sync.WaitGroup
+	for w := 0; w < s.opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.processRegion(ctx, units[i])
+				s.recordRegion(ctx, results[i])
+				if results[i].Err == nil {
+					continue
+				}
+
+				mu.Lock()
+				failed++
+				failedSoFar := failed
+				mu.Unlock()
+				if policy.shouldStop(failedSoFar, len(units)) {
+					stopOnce.Do(func() {
+						logging.Warnf(s.log, "Kebijakan kegagalan %s terlampaui (%d gagal), wilayah berikutnya tidak diproses.", policy, failedSoFar)
+						close(stop)
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i, unit := range units {
+		// Hentikan pembagian pekerjaan jika context sudah dibatalkan
+		if ctx.Err() != nil {
+			results[i] = unit.result()
+			results[i].Err = regionError(unit, "schedule", ctx.Err())
+			continue
+		}
+		select {
+		case jobs <- i:
+		case <-stop:
+			skipRemaining(results, units, i)
+			break dispatch
+		case <-s.opts.Interrupt:
+			logging.Warnf(s.log, "Interupsi diterima, menunggu wilayah yang sedang berjalan selesai; %d wilayah tidak diproses.", len(units)-i)
+			skipRemaining(results, units, i)
+			interrupted = true
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	select {
+	case <-stop:
+		stopped = true
+	default:
 	}
+	return results, stopped, interrupted
+}
 
-	s.log.Printf("Akan memproses data untuk %d kabupaten/kota...", len(daftarWilayah))
-	// 'startProcessing' akan menjadi 'true' setelah kita menemukan kabupaten awal
-	// Jika tidak ada flag -kab, langsung set ke true.
-	startProcessing := (startKabupaten == "")
-
-	for _, wilayah := range daftarWilayah {
-		// Jika kita belum sampai ke titik awal, cek apakah ini titik awalnya
-		if !startProcessing {
-			// Jika kode kabupaten saat ini cocok dengan flag, mulai proses dari sini
-			if wilayah.KodeKabupaten == startKabupaten {
-				s.log.Printf("Titik awal ditemukan. Memulai proses dari Kabupaten: %s", startKabupaten)
-				startProcessing = true
-			} else {
-				// Jika tidak cocok, lewati kabupaten ini
-				s.log.Printf("Melewati Kabupaten: %s (sebelum titik awal)", wilayah.KodeKabupaten)
+// skipRemaining menandai unit mulai dari indeks from sebagai Skipped.
+func skipRemaining(results []RegionResult, units []workUnit, from int) {
+	for j := from; j < len(units); j++ {
+		results[j] = units[j].result()
+		results[j].Skipped = true
+	}
+}
+
+// retryFailed memproses ulang wilayah yang gagal dalam beberapa putaran
+// tambahan. Hasil di result diganti dengan hasil putaran terakhir, sehingga
+// hanya wilayah yang tetap gagal yang dilaporkan sebagai kegagalan akhir.
+// Kebijakan kegagalan tidak menghentikan putaran ulang karena kegagalannya
+// sudah diperhitungkan di putaran utama.
+func (s *AnggaranDetailSynchronizer) retryFailed(ctx context.Context, result *SyncResult) {
+	for pass := 1; pass <= s.opts.RetryPasses; pass++ {
+		var indexes []int
+		var units []workUnit
+		for i, r := range result.Regions {
+			if r.Err != nil {
+				indexes = append(indexes, i)
+				units = append(units, r.unit())
+			}
+		}
+		if len(indexes) == 0 {
+			return
+		}
+
+		logging.Infof(s.log, "Putaran ulang %d/%d: %d wilayah gagal akan dicoba lagi setelah jeda %s...", pass, s.opts.RetryPasses, len(indexes), s.opts.RetryCooldown)
+		if err := s.cooldown(ctx, s.opts.RetryCooldown); err != nil {
+			logging.Warnf(s.log, "Putaran ulang dibatalkan: %v", err)
+			result.Interrupted = errors.Is(err, errInterrupted)
+			return
+		}
+
+		retried, _, interrupted := s.runWorkers(ctx, units, FailurePolicy{Mode: PolicyContinue})
+		for j, i := range indexes {
+			// Wilayah yang batal diulang karena interupsi tetap memakai hasil sebelumnya
+			if retried[j].Skipped {
 				continue
 			}
+			retried[j].Attempts = result.Regions[i].Attempts + 1
+			result.Regions[i] = retried[j]
 		}
-		// Proses sinkronisasi hanya berjalan jika startProcessing sudah true
-		s.log.Printf("=== Memproses Provinsi: %s, Kabupaten: %s ===", wilayah.KodeProvinsi, wilayah.KodeKabupaten)
-		// Fetch data untuk wilayah saat ini
-		// Perhatikan bagaimana memberikan kode wilayah sebagai argumen
-		details, err := s.fetcher.FetchAnggaranDetails(ctx, wilayah.KodeProvinsi, wilayah.KodeKabupaten)
-		if err != nil {
-			s.log.Printf("ERROR saat mengambil data untuk Prov %s Kab %s: %v. Melanjutkan ke wilayah berikutnya.", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
-			continue // Lanjut ke iterasi berikutnya jika ada error
+		if interrupted {
+			result.Interrupted = true
+			return
 		}
+	}
+}
 
-		if len(details) == 0 {
-			s.log.Println("Tidak ada data untuk wilayah ini.")
-			continue
+// errInterrupted dikembalikan cooldown jika Options.Interrupt ditutup.
+var errInterrupted = errors.New("sync interrupted")
+
+// cooldown menunggu selama d, atau berhenti lebih awal jika context dibatalkan
+// atau Options.Interrupt ditutup.
+func (s *AnggaranDetailSynchronizer) cooldown(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.opts.Interrupt:
+		return errInterrupted
+	case <-timer.C:
+		return nil
+	}
+}
+
+// processRegion mengambil, mentransformasi dan menyimpan data satu unit
+// (kabupaten, kecamatan atau desa) untuk satu tahun. Setiap baris log diberi
+// prefix tahun dan kode wilayah agar tetap terbaca saat paralel.
+func (s *AnggaranDetailSynchronizer) processRegion(ctx context.Context, unit workUnit) (result RegionResult) {
+	wilayah := unit.Wilayah
+	logger := s.unitLogger(unit)
+	result = unit.result()
+	result.Attempts = 1
+	result.StartedAt = time.Now()
+	defer func() { result.Duration = time.Since(result.StartedAt) }()
+
+	logging.Infof(logger, "=== Memproses Provinsi: %s, Kabupaten: %s ===", wilayah.KodeProvinsi, wilayah.KodeKabupaten)
+	checkpoint := s.startCheckpoint(ctx, unit, logger)
+	defer func() { checkpoint.finish(ctx, result.Err) }()
+
+	if s.opts.RegionTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.RegionTimeout)
+		defer cancel()
+	}
+
+	// Writer menentukan apakah setiap halaman langsung di-commit atau
+	// seluruh wilayah di-commit sekaligus di akhir (sesuai konfigurasi storer).
+	writer, err := s.storer.BeginRegion(ctx, regionScope(unit))
+	if err != nil {
+		logging.Errorf(logger, "ERROR saat membuka transaksi untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		result.Err = regionError(unit, "begin", err)
+		return result
+	}
+	defer writer.Rollback()
+
+	// Fetch data untuk wilayah saat ini secara streaming: setiap halaman
+	// langsung ditransformasi dan disimpan sebelum halaman berikutnya diambil.
+	var storeErr, scopeErr error
+	err = s.fetcher.StreamAnggaranDetails(ctx, unit.query(logger), func(ctx context.Context, page fetcher.Page) error {
+		result.Pages++
+		if len(page.Records) == 0 {
+			return nil
+		}
+		// Tolak halaman jika API mengabaikan filter kd_kec/kd_desa
+		if err := checkScope(unit, page.Records); err != nil {
+			scopeErr = err
+			return err
 		}
 
 		// Transformasi data (jika ada)
-		s.log.Println("Transforming data...")
-		transformedDetails := transformDetails(details)
-		s.log.Println("Data transformation complete.")
+		transformedDetails := transformDetails(page.Records)
 
-		// Simpan data ke database (menggunakan batch processing)
-		if err := s.storer.StoreAnggaranDetails(ctx, transformedDetails); err != nil {
-			s.log.Printf("ERROR saat menyimpan data untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
-			continue
+		// Simpan data halaman ini ke database
+		stats, err := writer.Store(ctx, transformedDetails)
+		if err != nil {
+			storeErr = err
+			return err
+		}
+		result.Writes.Add(stats)
+		result.Stored += len(transformedDetails)
+		checkpoint.page(ctx, page.URL, result.Stored)
+		logging.Infof(logger, "Halaman %d: %d data disimpan (total %d).", page.Number, len(transformedDetails), result.Stored)
+		return nil
+	})
+	if scopeErr != nil {
+		logging.Errorf(logger, "ERROR data dari API di luar wilayah yang diminta: %v", scopeErr)
+		result.Err = regionError(unit, "validate", scopeErr)
+		return result
+	}
+	if storeErr != nil {
+		logging.Errorf(logger, "ERROR saat menyimpan data untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, storeErr)
+		result.Err = regionError(unit, "store", storeErr)
+		return result
+	}
+	if err != nil {
+		logging.Errorf(logger, "ERROR saat mengambil data untuk Prov %s Kab %s: %v. Melanjutkan ke wilayah berikutnya.", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		result.Err = regionError(unit, "fetch", err)
+		return result
+	}
+
+	// Rekonsiliasi hanya dijalankan jika fetch lengkap. Kabupaten yang kosong
+	// dilewati supaya satu respons kosong tidak menghapus seluruh kabupaten;
+	// kecamatan/desa yang kosong tetap direkonsiliasi agar datanya ikut hilang
+	// dan tidak lagi dipecah menjadi unit pada run berikutnya.
+	if result.Stored > 0 || unit.KodeKecamatan != "" {
+		deleted, err := writer.Reconcile(ctx)
+		if err != nil {
+			logging.Errorf(logger, "ERROR saat rekonsiliasi data untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+			result.Err = regionError(unit, "reconcile", err)
+			return result
+		}
+		result.Deleted = deleted
+		if deleted > 0 {
+			logging.Infof(logger, "Rekonsiliasi: %d baris yang tidak ada lagi di API dihapus.", deleted)
 		}
+	}
 
-		s.log.Printf("=== Selesai memproses untuk Provinsi: %s, Kabupaten: %s. Total %d data disimpan. ===", wilayah.KodeProvinsi, wilayah.KodeKabupaten, len(details))
+	if err := writer.Commit(); err != nil {
+		logging.Errorf(logger, "ERROR saat menyimpan data untuk Prov %s Kab %s: %v", wilayah.KodeProvinsi, wilayah.KodeKabupaten, err)
+		result.Err = regionError(unit, "commit", err)
+		return result
+	}
 
-		// Opsional: Beri jeda singkat antar request untuk tidak membebani API
-		s.log.Println("Memberi jeda 30 Detik...")
-		time.Sleep(30 * time.Second)
+	if result.Stored == 0 {
+		logging.Infof(logger, "Tidak ada data untuk wilayah ini.")
+		return result
 	}
 
-	s.log.Println("Semua proses sinkronisasi untuk seluruh wilayah telah selesai.")
-	return nil
+	logging.Infof(logger, "=== Selesai memproses untuk Provinsi: %s, Kabupaten: %s. Total %d data disimpan (%d baru, %d berubah, %d tetap). ===",
+		wilayah.KodeProvinsi, wilayah.KodeKabupaten, result.Stored, result.Writes.Inserted, result.Writes.Updated, result.Writes.Unchanged)
+	return result
+}
+
+// regionScope mengubah unit menjadi scope dengan format kode yang tersimpan di
+// tabel, mengikuti aturan yang sama dengan transformDetails.
+func regionScope(unit workUnit) storer.RegionScope {
+	prov, kab := unit.Wilayah.KodeProvinsi, unit.Wilayah.KodeKabupaten
+	scope := storer.RegionScope{
+		Tahun:         strconv.Itoa(unit.Tahun),
+		KodeProvinsi:  prov,
+		KodeKabupaten: fmt.Sprintf("%s.%s", prov, kab),
+	}
+	if unit.KodeKecamatan != "" {
+		scope.KodeKecamatan = fmt.Sprintf("%s.%s.%s", prov, kab, unit.KodeKecamatan)
+	}
+	if unit.KodeDesa != "" {
+		scope.KodeDesa = fmt.Sprintf("%s.%s.%s", prov, kab, unit.KodeDesa)
+	}
+	return scope
+}
+
+// query mengubah unit menjadi parameter request API; log fetch ditulis ke logger.
+func (u workUnit) query(logger *log.Logger) fetcher.Query {
+	return fetcher.Query{
+		Tahun:  u.Tahun,
+		KdProv: u.Wilayah.KodeProvinsi,
+		KdKab:  u.Wilayah.KodeKabupaten,
+		KdKec:  u.KodeKecamatan,
+		KdDesa: u.KodeDesa,
+		Logger: logger,
+	}
+}
+
+// unitLogger membuat logger dengan prefix tahun dan kode wilayah unit, dipakai
+// juga oleh fetcher agar log fetch dan retry bisa dikaitkan ke wilayahnya.
+func (s *AnggaranDetailSynchronizer) unitLogger(unit workUnit) *log.Logger {
+	return log.New(s.log.Writer(), fmt.Sprintf("%s[%s] ", s.log.Prefix(), unit), s.log.Flags())
 }
 
 // transformDetails berisi logika untuk mengubah data
@@ -0,0 +1,78 @@
+package synchronizer
+
+import (
+	"context"
+	"math"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/fetcher"
+	"github.com/aryadiwwt/synctodb-anggarandetail/storer"
+)
+
+// amountTolerance adalah selisih total nilai yang masih dianggap sama,
+// untuk menyerap galat pembulatan float saat menjumlahkan.
+const amountTolerance = 0.005
+
+// VerifyResult adalah perbandingan API dan database untuk satu kabupaten/kota
+// pada satu tahun.
+type VerifyResult struct {
+	Tahun   int
+	Wilayah domain.Wilayah
+	API     storer.RegionSummary
+	DB      storer.RegionSummary
+	// Err diisi jika salah satu sisi tidak bisa dibaca
+	Err error
+}
+
+// Match bernilai true jika jumlah baris dan semua total nilai sama.
+func (r VerifyResult) Match() bool {
+	return r.Err == nil &&
+		r.API.Records == r.DB.Records &&
+		math.Abs(r.API.Anggaran1-r.DB.Anggaran1) <= amountTolerance &&
+		math.Abs(r.API.Anggaran2-r.DB.Anggaran2) <= amountTolerance &&
+		math.Abs(r.API.Realisasi1-r.DB.Realisasi1) <= amountTolerance &&
+		math.Abs(r.API.Realisasi2-r.DB.Realisasi2) <= amountTolerance
+}
+
+// Verify membandingkan jumlah baris dan total nilai di API dengan yang
+// tersimpan di database untuk setiap wilayah dan tahun, tanpa menulis apa pun.
+// Wilayah diperiksa berurutan agar tidak menambah beban ke API.
+func (s *AnggaranDetailSynchronizer) Verify(ctx context.Context, tahun []int, selection Selection) ([]VerifyResult, error) {
+	daftarWilayah, err := s.selectRegions(ctx, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]VerifyResult, 0, len(tahun)*len(daftarWilayah))
+	for _, t := range tahun {
+		for _, wilayah := range daftarWilayah {
+			if err := ctx.Err(); err != nil {
+				return results, err
+			}
+			results = append(results, s.verifyRegion(ctx, workUnit{Tahun: t, Wilayah: wilayah}))
+		}
+	}
+	return results, nil
+}
+
+// verifyRegion mengambil ringkasan satu wilayah dari API dan database.
+func (s *AnggaranDetailSynchronizer) verifyRegion(ctx context.Context, unit workUnit) VerifyResult {
+	result := VerifyResult{Tahun: unit.Tahun, Wilayah: unit.Wilayah}
+
+	err := s.fetcher.StreamAnggaranDetails(ctx, unit.query(s.unitLogger(unit)), func(_ context.Context, page fetcher.Page) error {
+		for _, detail := range page.Records {
+			result.API.Add(detail)
+		}
+		return nil
+	})
+	if err != nil {
+		result.Err = regionError(unit, "fetch", err)
+		return result
+	}
+
+	result.DB, err = s.storer.SummarizeRegion(ctx, regionScope(unit))
+	if err != nil {
+		result.Err = regionError(unit, "summarize", err)
+	}
+	return result
+}
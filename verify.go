@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/config"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+	"github.com/aryadiwwt/synctodb-anggarandetail/synchronizer"
+)
+
+// runVerify menjalankan subcommand "verify": membandingkan jumlah baris dan
+// total nilai di database dengan API tanpa menulis data. Exit code
+// exitRegionsFailed jika ada wilayah yang berbeda atau gagal diperiksa.
+func runVerify(cfg *config.Config, logger *log.Logger, args []string) int {
+	fs := newFlagSet("verify", "[flag]")
+	provinsiPtr := fs.String("prov", "", "Daftar kode provinsi yang dipisahkan koma (kosong berarti semua)")
+	wilayahPtr := fs.String("wilayah", "", "Pemilih wilayah seperti pada sync (contoh: 35,!35.78,51.03)")
+	allPtr := fs.Bool("all", false, "Tampilkan juga wilayah yang sudah cocok")
+	if code, ok := parseFlags(fs, args); !ok {
+		return code
+	}
+	if !requireCredentials(cfg, logger) {
+		return exitFatal
+	}
+	selection, ok := parseSelection(logger, *provinsiPtr, *wilayahPtr, "", "")
+	if !ok {
+		return exitUsage
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not connect to database: %v", err)
+		return exitFatal
+	}
+	defer db.Close()
+	source, err := newWilayahSource(db, cfg, logger)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Could not load wilayah source: %v", err)
+		return exitFatal
+	}
+
+	verifier := synchronizer.NewAnggaranDetailSynchronizer(newFetcher(cfg), newStorer(db, cfg, "", logger), source, logger, synchronizer.Options{})
+	results, err := verifier.Verify(context.Background(), cfg.APIDataTahun, selection)
+	if err != nil {
+		logging.Errorf(logger, "FATAL: Verification failed: %v", err)
+		return exitFatal
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "TAHUN\tPROV\tKAB\tAPI\tDB\tANGGARAN1 API\tANGGARAN1 DB\tREALISASI1 API\tREALISASI1 DB\tHASIL\t\n")
+	mismatches := 0
+	for _, r := range results {
+		verdict := "cocok"
+		switch {
+		case r.Err != nil:
+			verdict = "error: " + r.Err.Error()
+		case !r.Match():
+			verdict = "BERBEDA"
+		}
+		if !r.Match() {
+			mismatches++
+		} else if !*allPtr {
+			continue
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%s\t\n",
+			r.Tahun, r.Wilayah.KodeProvinsi, r.Wilayah.KodeKabupaten, r.API.Records, r.DB.Records,
+			r.API.Anggaran1, r.DB.Anggaran1, r.API.Realisasi1, r.DB.Realisasi1, verdict)
+	}
+	w.Flush()
+
+	fmt.Printf("%d wilayah diperiksa, %d berbeda atau gagal diperiksa.\n", len(results), mismatches)
+	if mismatches > 0 {
+		return exitRegionsFailed
+	}
+	return exitOK
+}
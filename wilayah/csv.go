@@ -0,0 +1,75 @@
+package wilayah
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+)
+
+// NewCSVSource membuat Source dari file CSV. Setiap baris berisi kd_prov,kd_kab
+// dan boleh diikuti kolom lain (misal nama) yang diabaikan. Baris header
+// opsional; baris yang diawali # dianggap komentar. File dibaca sekali saat
+// Source dibuat.
+func NewCSVSource(path string) (Source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka daftar wilayah: %w", err)
+	}
+	defer f.Close()
+
+	daftarWilayah, err := readCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return newListSource(daftarWilayah), nil
+}
+
+// readCSV membaca daftar wilayah dengan format yang dijelaskan di NewCSVSource.
+func readCSV(r io.Reader) ([]domain.Wilayah, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var daftarWilayah []domain.Wilayah
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) < 2 {
+			return nil, fmt.Errorf("baris %d: butuh kolom kd_prov dan kd_kab", line)
+		}
+
+		prov, kab := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		// Header dikenali dari kolom pertama yang bukan angka
+		if first && !isDigits(prov) {
+			continue
+		}
+		if !isDigits(prov) || !isDigits(kab) {
+			return nil, fmt.Errorf("baris %d: kode wilayah %q.%q tidak valid", line, prov, kab)
+		}
+		daftarWilayah = append(daftarWilayah, domain.Wilayah{KodeProvinsi: prov, KodeKabupaten: kab})
+	}
+	return daftarWilayah, nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package wilayah
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+)
+
+// wil menyingkat literal domain.Wilayah di tabel test.
+func wil(prov, kab string) domain.Wilayah {
+	return domain.Wilayah{KodeProvinsi: prov, KodeKabupaten: kab}
+}
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []domain.Wilayah
+	}{
+		{"plain", "51,03\n51,71\n", []domain.Wilayah{wil("51", "03"), wil("51", "71")}},
+		{"header and extra columns", "kd_prov,kd_kab,nama\n51,3,BADUNG\n", []domain.Wilayah{wil("51", "3")}},
+		{"comments and spaces", "# Bali\n51, 03\n\n# NTB\n 52 ,01\n", []domain.Wilayah{wil("51", "03"), wil("52", "01")}},
+		{"empty", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("readCSV: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{"missing column", "51,03\n52\n", "baris 2: butuh kolom kd_prov dan kd_kab"},
+		{"non-numeric code", "51,03\n51,XX\n", `baris 2: kode wilayah "51"."XX" tidak valid`},
+		// Hanya baris pertama yang boleh berupa header
+		{"header after data", "51,03\nkd_prov,kd_kab\n", "baris 2: kode wilayah"},
+		{"empty code", "51,\n", "baris 1: kode wilayah"},
+		{"bad quoting", "51,\"03\n", "extraneous or missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readCSV(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSVSourceFormatsAndFilters(t *testing.T) {
+	daftar, err := readCSV(strings.NewReader("52,1\n51,71\n51,3\n"))
+	if err != nil {
+		t.Fatalf("readCSV: %v", err)
+	}
+	source := newListSource(daftar)
+
+	got, err := source.GetWilayahByProvinsi(context.Background(), []string{"51"})
+	if err != nil {
+		t.Fatalf("GetWilayahByProvinsi: %v", err)
+	}
+	want := []domain.Wilayah{wil("51", "03"), wil("51", "71")}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	all, _ := source.GetWilayahByProvinsi(context.Background(), nil)
+	if len(all) != 3 || all[2] != wil("52", "01") {
+		t.Errorf("got %v, want all three sorted with 2-digit kabupaten codes", all)
+	}
+}
+
+func TestStaticSourceLoads(t *testing.T) {
+	source, err := NewStaticSource()
+	if err != nil {
+		t.Fatalf("NewStaticSource: %v", err)
+	}
+	bali, _ := source.GetWilayahByProvinsi(context.Background(), []string{"51"})
+	if !slices.Contains(bali, wil("51", "03")) {
+		t.Errorf("static list for province 51 has no kabupaten 03: %v", bali)
+	}
+}
@@ -0,0 +1,86 @@
+// Package wilayah menyediakan daftar kabupaten/kota yang akan disinkronkan
+// dari beberapa sumber: tabel referensi di database, file CSV, atau daftar
+// bawaan yang ikut dikompilasi.
+package wilayah
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+	"github.com/aryadiwwt/synctodb-anggarandetail/logging"
+)
+
+// Jenis sumber wilayah yang didukung.
+const (
+	// KindSQL membaca tabel referensi di database (bawaan: master_kota).
+	KindSQL = "sql"
+	// KindCSV membaca file CSV berisi kd_prov,kd_kab.
+	KindCSV = "csv"
+	// KindStatic memakai daftar kabupaten/kota bawaan.
+	KindStatic = "static"
+)
+
+// Source mendefinisikan kontrak untuk menemukan wilayah yang akan diproses.
+type Source interface {
+	// GetWilayahByProvinsi mengembalikan kabupaten/kota di provinsi yang diminta
+	// (kosong berarti semua), diurutkan per kode provinsi lalu kabupaten, dengan
+	// kode kabupaten berformat 2 digit.
+	GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]domain.Wilayah, error)
+}
+
+// listSource adalah Source di atas daftar yang sudah dimuat di memori,
+// dipakai oleh sumber CSV dan daftar bawaan.
+type listSource struct {
+	daftarWilayah []domain.Wilayah
+}
+
+func newListSource(daftarWilayah []domain.Wilayah) *listSource {
+	// Kode sudah divalidasi readCSV sehingga formatKabupaten tidak akan memberi peringatan
+	formatKabupaten(daftarWilayah, nil)
+	sort.SliceStable(daftarWilayah, func(i, j int) bool {
+		if daftarWilayah[i].KodeProvinsi != daftarWilayah[j].KodeProvinsi {
+			return daftarWilayah[i].KodeProvinsi < daftarWilayah[j].KodeProvinsi
+		}
+		return daftarWilayah[i].KodeKabupaten < daftarWilayah[j].KodeKabupaten
+	})
+	return &listSource{daftarWilayah: daftarWilayah}
+}
+
+func (s *listSource) GetWilayahByProvinsi(_ context.Context, kodeProvinsi []string) ([]domain.Wilayah, error) {
+	wanted := make(map[string]bool, len(kodeProvinsi))
+	for _, kode := range kodeProvinsi {
+		wanted[kode] = true
+	}
+
+	var wilayah []domain.Wilayah
+	for _, w := range s.daftarWilayah {
+		if len(wanted) == 0 || wanted[w.KodeProvinsi] {
+			wilayah = append(wilayah, w)
+		}
+	}
+	return wilayah, nil
+}
+
+// formatKabupaten mengubah kode kabupaten menjadi string 2 digit dengan awalan nol.
+// Kode yang bukan angka ditulis sebagai peringatan ke logger (nil berarti logger standar).
+func formatKabupaten(wilayah []domain.Wilayah, logger *log.Logger) {
+	for i := range wilayah {
+		// Ambil kode kabupaten mentah
+		kodeKabStr := wilayah[i].KodeKabupaten
+
+		// Ubah string menjadi integer
+		num, err := strconv.Atoi(kodeKabStr)
+		if err != nil {
+			// Jika gagal (misal format tidak standar), biarkan apa adanya dan beri peringatan
+			logging.Warnf(logger, "Peringatan: Format kd_kab '%s' tidak valid, tidak diformat.", kodeKabStr)
+			continue
+		}
+
+		// Format integer menjadi string 2 digit dengan awalan nol
+		wilayah[i].KodeKabupaten = fmt.Sprintf("%02d", num)
+	}
+}
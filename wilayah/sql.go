@@ -0,0 +1,114 @@
+package wilayah
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/aryadiwwt/synctodb-anggarandetail/domain"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// SQLOptions menentukan tabel dan kolom referensi wilayah. Nilai kosong
+// memakai skema master_kota(provinsi_id, kota_id).
+type SQLOptions struct {
+	// Table boleh memakai nama skema, misal "referensi.kabupaten"
+	Table           string
+	ProvinsiColumn  string
+	KabupatenColumn string
+	// Logger menerima peringatan kode wilayah yang tidak valid; nil berarti logger standar
+	Logger *log.Logger
+}
+
+// identifierPattern membatasi nama tabel/kolom yang boleh dipakai, karena
+// nama tersebut disisipkan langsung ke query.
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+type sqlSource struct {
+	db *sqlx.DB
+	// selectQuery, provinsiColumn dan orderBy sudah di-quote
+	selectQuery    string
+	provinsiColumn string
+	orderBy        string
+	logger         *log.Logger
+}
+
+// NewSQLSource membuat Source yang membaca tabel referensi di database.
+func NewSQLSource(db *sqlx.DB, opts SQLOptions) (Source, error) {
+	if opts.Table == "" {
+		opts.Table = "master_kota"
+	}
+	if opts.ProvinsiColumn == "" {
+		opts.ProvinsiColumn = "provinsi_id"
+	}
+	if opts.KabupatenColumn == "" {
+		opts.KabupatenColumn = "kota_id"
+	}
+	for _, name := range []string{opts.Table, opts.ProvinsiColumn, opts.KabupatenColumn} {
+		if !identifierPattern.MatchString(name) {
+			return nil, fmt.Errorf("nama tabel/kolom wilayah tidak valid: %q", name)
+		}
+	}
+
+	prov := quoteIdentifier(opts.ProvinsiColumn)
+	kab := quoteIdentifier(opts.KabupatenColumn)
+	return &sqlSource{
+		db: db,
+		// Kolom diberi alias sesuai tag db domain.Wilayah; ::text agar kolom
+		// bertipe angka tetap bisa dibaca sebagai kode
+		selectQuery:    fmt.Sprintf(`SELECT %s::text AS provinsi_id, %s::text AS kota_id FROM %s`, prov, kab, quoteIdentifier(opts.Table)),
+		provinsiColumn: prov,
+		orderBy:        prov + `, ` + kab,
+		logger:         opts.Logger,
+	}, nil
+}
+
+// quoteIdentifier meng-quote nama yang mungkin memakai prefix skema.
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = pq.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// Implementasi fungsi untuk memfilter berdasarkan kd_prov
+func (s *sqlSource) GetWilayahByProvinsi(ctx context.Context, kodeProvinsi []string) ([]domain.Wilayah, error) {
+	var wilayah []domain.Wilayah
+
+	// Query dasar
+	baseQuery := s.selectQuery
+
+	var args []interface{}
+
+	// Jika daftar provinsi diberikan, tambahkan klausa WHERE IN
+	if len(kodeProvinsi) > 0 {
+		baseQuery += ` WHERE ` + s.provinsiColumn + `::text IN (?)`
+		args = append(args, kodeProvinsi)
+	}
+
+	baseQuery += ` ORDER BY ` + s.orderBy
+
+	// sqlx.In secara aman akan mengubah query (?) menjadi ($1, $2, ...)
+	// dan menyesuaikan argumennya. Ini cara aman untuk klausa IN.
+	query, args, err := sqlx.In(baseQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat query IN: %w", err)
+	}
+
+	// Rebind query agar sesuai dengan placeholder PostgreSQL ($1, $2)
+	query = s.db.Rebind(query)
+
+	err = s.db.SelectContext(ctx, &wilayah, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mengambil data wilayah yang difilter: %w", err)
+	}
+
+	// Lakukan loop untuk memformat kode kabupaten setelah data didapat
+	formatKabupaten(wilayah, s.logger)
+	return wilayah, nil
+}
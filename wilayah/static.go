@@ -0,0 +1,22 @@
+package wilayah
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// kabupatenKota adalah daftar kode kabupaten/kota Kemendagri yang ikut
+// dikompilasi, dipakai jika tidak ada tabel referensi maupun file CSV.
+//
+//go:embed kabupaten_kota.csv
+var kabupatenKota string
+
+// NewStaticSource membuat Source dari daftar kabupaten/kota bawaan.
+func NewStaticSource() (Source, error) {
+	daftarWilayah, err := readCSV(strings.NewReader(kabupatenKota))
+	if err != nil {
+		return nil, fmt.Errorf("daftar wilayah bawaan: %w", err)
+	}
+	return newListSource(daftarWilayah), nil
+}